@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// toStringE 将配置值转换为字符串
+func toStringE(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case map[string]interface{}, []interface{}:
+		return "", fmt.Errorf("cannot convert %T to string", val)
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// toBoolE 将配置值转换为布尔值，支持 "true"/"1"/"yes"/"on" 等字符串
+func toBoolE(val interface{}) (bool, error) {
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case int:
+		return v != 0, nil
+	case int64:
+		return v != 0, nil
+	case uint64:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "1", "t", "true", "y", "yes", "on":
+			return true, nil
+		case "0", "f", "false", "n", "no", "off", "":
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot convert %q to bool", v)
+	default:
+		return false, fmt.Errorf("cannot convert %T to bool", val)
+	}
+}
+
+// toInt64E 将配置值转换为 int64
+func toInt64E(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", v)
+		}
+		return int64(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("cannot convert %v to integer", v)
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && f == math.Trunc(f) {
+			return int64(f), nil
+		}
+		return 0, fmt.Errorf("cannot convert %q to integer", v)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to integer", val)
+	}
+}
+
+// toInt64TruncE 与 toInt64E 相同，但小数按截断处理，如 1.9、"1.5" 分别为 1
+// 只用于 GetInt、GetInt64 等宽松读取，解码与 Must 系列仍要求整数
+func toInt64TruncE(val interface{}) (int64, error) {
+	i, err := toInt64E(val)
+	if err == nil {
+		return i, nil
+	}
+	f, ferr := toFloat64E(val)
+	if ferr != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, err
+	}
+	return int64(f), nil
+}
+
+// toUint64E 将配置值转换为 uint64
+func toUint64E(val interface{}) (uint64, error) {
+	switch v := val.(type) {
+	case uint64:
+		return v, nil
+	case string:
+		if u, err := strconv.ParseUint(strings.TrimSpace(v), 0, 64); err == nil {
+			return u, nil
+		}
+	}
+
+	i, err := toInt64E(val)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("cannot convert negative %d to unsigned integer", i)
+	}
+	return uint64(i), nil
+}
+
+// toFloat64E 将配置值转换为 float64
+func toFloat64E(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to float", v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to float", val)
+	}
+}
+
+// toDurationE 将配置值转换为 time.Duration
+// 字符串按 time.ParseDuration 解析（如 "1s"、"500ms"），纯数字按秒处理
+func toDurationE(val interface{}) (time.Duration, error) {
+	switch v := val.(type) {
+	case time.Duration:
+		return v, nil
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case int64:
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case string:
+		s := strings.TrimSpace(v)
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return time.Duration(f * float64(time.Second)), nil
+		}
+		return 0, fmt.Errorf("cannot convert %q to duration", v)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to duration", val)
+	}
+}
+
+// toTimeE 将配置值转换为 time.Time，支持 RFC3339、日期时间、日期及 Unix 时间戳
+func toTimeE(val interface{}) (time.Time, error) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, nil
+	case int, int64, uint64:
+		sec, err := toInt64E(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(sec, 0), nil
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range []string{time.RFC3339Nano, time.RFC3339, time.DateTime, time.DateOnly} {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot convert %q to time", v)
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to time", val)
+	}
+}
+
+// toStringSliceE 将配置值转换为字符串数组，字符串按逗号分隔
+func toStringSliceE(val interface{}) ([]string, error) {
+	items, ok := toItems(val)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %T to string slice", val)
+	}
+
+	s := make([]string, len(items))
+	for i, item := range items {
+		str, err := toStringE(item)
+		if err != nil {
+			return nil, err
+		}
+		s[i] = str
+	}
+	return s, nil
+}
+
+// toStringMapStringE 将 map 配置转换为 map[string]string
+func toStringMapStringE(val interface{}) (map[string]string, error) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %T to string map", val)
+	}
+
+	result := make(map[string]string, len(m))
+	for k, item := range m {
+		str, err := toStringE(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		result[k] = str
+	}
+	return result, nil
+}
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// toSizeInBytesE 将 "512MB"、"1.5g"、"1024" 等容量转换为字节数，单位按 1024 进制
+func toSizeInBytesE(val interface{}) (int64, error) {
+	s, ok := val.(string)
+	if !ok {
+		return toInt64E(val)
+	}
+
+	s = strings.TrimSpace(s)
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
+		i--
+	}
+	num, unit := strings.TrimSpace(s[:i]), strings.ToLower(strings.TrimSpace(s[i:]))
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok || num == "" {
+		return 0, fmt.Errorf("cannot convert %q to size", s)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("cannot convert %q to size", s)
+	}
+	if f*float64(multiplier) > math.MaxInt64 {
+		return 0, fmt.Errorf("%q overflows int64", s)
+	}
+	return int64(f * float64(multiplier)), nil
+}
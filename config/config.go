@@ -0,0 +1,326 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config 一份独立的配置实例，可在同一进程中创建多份互不影响的配置
+type Config struct {
+	file string
+	opts options
+
+	// st 当前生效的配置，state 创建后不再修改，Reload 时整体原子替换
+	st atomic.Pointer[state]
+
+	reloadMu    sync.Mutex
+	subMu       sync.Mutex
+	subscribers []subscriber
+	errHandlers []func(error)
+}
+
+// Load 加载配置文件（自动加载 .env），出错时返回错误而不是 panic
+// Load 不执行规则校验，需要时调用 Validate
+func Load(file string, opts ...Option) (*Config, error) {
+	// 加载 .env 文件（忽略不存在错误）
+	// 首先尝试加载配置文件同目录下的 .env，然后尝试当前目录
+	loadEnvFiles(envFiles(file)...)
+
+	o := newOptions(opts...)
+	st, err := loadState(file, o)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(file, o, st), nil
+}
+
+func newConfig(file string, o options, st *state) *Config {
+	c := &Config{file: file, opts: o}
+	c.st.Store(st)
+	return c
+}
+
+// state 一次加载得到的配置数据，创建后只读，可以在多个 goroutine 间共享
+type state struct {
+	data map[string]interface{}
+	// origins 配置路径 -> 来源位置，环境变量覆盖的值来源为 env:<变量名>
+	origins map[string]origin
+	// files 参与加载的全部文件
+	files []string
+	// secrets 由密钥引用解析得到的配置路径
+	secrets map[string]bool
+}
+
+// LoadFS 从 fsys（如 //go:embed 的 embed.FS）加载配置文件，覆盖文件与 include 片段同样从 fsys 读取
+//
+//	//go:embed conf
+//	var confFS embed.FS
+//
+//	c, err := config.LoadFS(confFS, "conf/config.yaml")
+func LoadFS(fsys fs.FS, file string, opts ...Option) (*Config, error) {
+	return Load(file, append(opts, WithFS(fsys))...)
+}
+
+// LoadReader 从 r 读取 format 格式（yaml、json、toml 或 RegisterFormat 注册的格式）的配置
+// 不加载覆盖文件，include 路径相对于当前目录；该配置没有文件，不能 Reload
+func LoadReader(r io.Reader, format string, opts ...Option) (*Config, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts...)
+	l := newLoader(o.fsys)
+	name := "<reader>"
+	root, err := parseContent(name, format, content)
+	if err != nil {
+		return nil, err
+	}
+	if root, err = l.prepare(root, name, nil); err != nil {
+		return nil, err
+	}
+	st, err := l.build(root, name, o)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig("", o, st), nil
+}
+
+// LoadEnv 只使用环境变量构建配置，如 GUTILS_APP__NAME=demo 对应 app.name
+// 路径按小写生成，值按 YAML 规则推断类型
+func LoadEnv(opts ...Option) (*Config, error) {
+	o := newOptions(opts...)
+	l := newLoader(nil)
+	st, err := l.build(&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, "<env>", o)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig("", o, st), nil
+}
+
+// loadState 按层加载基础文件、覆盖文件及其引入的片段，最后应用环境变量覆盖
+func loadState(file string, o options) (*state, error) {
+	l := newLoader(o.fsys)
+	root, err := l.layered(file, o)
+	if err != nil {
+		return nil, err
+	}
+	return l.build(root, file, o)
+}
+
+// build 依次应用远程来源、环境变量、命令行参数与默认值，解析配置项引用并生成配置数据
+func (l *loader) build(root *yaml.Node, name string, o options) (*state, error) {
+	l.applySources(root, o)
+	if err := l.applyEnv(root, o); err != nil {
+		return nil, err
+	}
+	if err := l.applyFlags(root); err != nil {
+		return nil, err
+	}
+	if err := l.applyDefaults(root); err != nil {
+		return nil, err
+	}
+	if err := l.resolveRefs(root); err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := root.Decode(&data); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return &state{data: data, origins: l.origins(root), files: l.files, secrets: l.secretPaths(root)}, nil
+}
+
+// File 返回基础配置文件路径
+func (c *Config) File() string {
+	return c.file
+}
+
+// Files 返回参与加载的全部文件：基础文件、include 引入的片段以及覆盖文件（含尚不存在的）
+func (c *Config) Files() []string {
+	return append([]string(nil), c.state().files...)
+}
+
+// Origin 返回配置值来自哪个文件，路径不存在时返回空字符串
+func (c *Config) Origin(path string) string {
+	return c.state().origins[path].file
+}
+
+// Position 返回配置值的来源位置 file:line，路径不存在时返回空字符串
+func (c *Config) Position(path string) string {
+	return c.state().origins[path].String()
+}
+
+// state 获取当前生效的配置
+func (c *Config) state() *state {
+	if st := c.st.Load(); st != nil {
+		return st
+	}
+	return &state{}
+}
+
+// Snapshot 返回当前配置的只读快照，之后的 Reload 不影响快照
+// 一次请求中多次读取配置时使用同一个快照，可以保证看到的配置一致
+//
+//	snap := config.Snapshot()
+//	host, port := snap.GetString("redis.default.host"), snap.GetInt("redis.default.port")
+func (c *Config) Snapshot() *Config {
+	return newConfig("", c.opts, c.state())
+}
+
+// conf 获取当前生效的配置根节点
+func (c *Config) conf() map[string]interface{} {
+	return c.state().data
+}
+
+// Section 获取指定节点的配置（兼容旧 API），返回副本，修改不影响配置
+func (c *Config) Section(name string) map[string]interface{} {
+	val, ok := c.conf()[name]
+	if !ok {
+		return map[string]interface{}{}
+	}
+
+	section, ok := val.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+
+	return copyValue(section).(map[string]interface{})
+}
+
+// Key 获取 app section 下的 key（兼容旧 API）
+func (c *Config) Key(name string) string {
+	appSection := c.Section("app")
+	val, ok := appSection[name]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// getValueByPath 通过路径获取配置值
+func (c *Config) getValueByPath(path string) (interface{}, bool) {
+	return lookup(c.conf(), path)
+}
+
+// lookup 在指定配置根节点下按路径查找
+func lookup(conf map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	var current interface{} = conf
+
+	for _, part := range parts {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			if next, ok := v[part]; ok {
+				current = next
+			} else {
+				return nil, false
+			}
+		case []interface{}:
+			// 处理数组索引
+			idx := 0
+			if _, err := fmt.Sscanf(part, "%d", &idx); err == nil && idx < len(v) {
+				current = v[idx]
+			} else {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// GetString 通过路径获取字符串配置值
+func (c *Config) GetString(path string) string {
+	val, ok := c.getValueByPath(path)
+	if !ok || val == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// GetStringWithDefault 通过路径获取字符串配置值，带默认值
+func (c *Config) GetStringWithDefault(path string, defaultValue string) string {
+	val, ok := c.getValueByPath(path)
+	if !ok || val == nil {
+		return defaultValue
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// GetInt 通过路径获取整数配置值，字符串如 "8080" 会被转换，小数按截断处理
+func (c *Config) GetInt(path string) int {
+	return c.GetIntWithDefault(path, 0)
+}
+
+// GetIntWithDefault 通过路径获取整数配置值，带默认值
+func (c *Config) GetIntWithDefault(path string, defaultValue int) int {
+	return int(getWithDefault(c, path, int64(defaultValue), toInt64TruncE))
+}
+
+// GetBool 通过路径获取布尔配置值，字符串如 "true"、"1"、"on" 会被转换
+func (c *Config) GetBool(path string) bool {
+	return c.GetBoolWithDefault(path, false)
+}
+
+// GetBoolWithDefault 通过路径获取布尔配置值，带默认值
+func (c *Config) GetBoolWithDefault(path string, defaultValue bool) bool {
+	return getWithDefault(c, path, defaultValue, toBoolE)
+}
+
+// GetStringMap 通过路径获取 map 类型配置，返回副本，修改不影响配置
+func (c *Config) GetStringMap(path string) map[string]interface{} {
+	val, ok := c.getValueByPath(path)
+	if !ok {
+		return map[string]interface{}{}
+	}
+
+	if m, ok := val.(map[string]interface{}); ok {
+		return copyValue(m).(map[string]interface{})
+	}
+	return map[string]interface{}{}
+}
+
+// GetSlice 通过路径获取数组类型配置，返回副本，修改不影响配置
+func (c *Config) GetSlice(path string) []interface{} {
+	val, ok := c.getValueByPath(path)
+	if !ok {
+		return []interface{}{}
+	}
+
+	if s, ok := val.([]interface{}); ok {
+		return copyValue(s).([]interface{})
+	}
+	return []interface{}{}
+}
+
+// copyValue 深拷贝配置中的 map 与数组，state 在多个 goroutine 间共享，不能交给调用方修改
+func copyValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = copyValue(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = copyValue(item)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	os.Clearenv()
+	c, err := Load(filepath.Join("testdata", "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	if c.GetString("app.name") != "my-app" {
+		t.Errorf("app.name expected 'my-app', got '%s'", c.GetString("app.name"))
+	}
+	if c.GetInt("log.maxsize") != 1024 {
+		t.Errorf("log.maxsize expected 1024, got %d", c.GetInt("log.maxsize"))
+	}
+	if len(c.GetSlice("database.test.slaves")) != 1 {
+		t.Errorf("database.test.slaves expected 1 element")
+	}
+}
+
+func TestGetStringMapCopy(t *testing.T) {
+	os.Clearenv()
+	c, err := Load(filepath.Join("testdata", "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	// 修改返回值不影响配置
+	c.GetStringMap("app")["name"] = "changed"
+	c.Section("app")["name"] = "changed"
+	db := c.GetStringMap("database")
+	delete(db["test"].(map[string]interface{}), "slaves")
+	c.GetSlice("database.test.slaves")[0] = nil
+	if c.GetString("app.name") != "my-app" {
+		t.Errorf("app.name changed to %q", c.GetString("app.name"))
+	}
+	if s := c.GetSlice("database.test.slaves"); len(s) != 1 || s[0] == nil {
+		t.Errorf("database.test.slaves changed to %v", s)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	os.Clearenv()
+	if _, err := Load(filepath.Join("testdata", "missing.yaml")); err == nil {
+		t.Errorf("Load expected error for missing file")
+	}
+
+	file := filepath.Join(t.TempDir(), "bad.yaml")
+	writeFile(t, file, "app: [unclosed\n")
+	if _, err := Load(file); err == nil {
+		t.Errorf("Load expected error for bad yaml")
+	}
+}
+
+func TestLoadIndependent(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.yaml")
+	fileB := filepath.Join(dir, "b.yaml")
+	writeFile(t, fileA, "app:\n  name: a\n")
+	writeFile(t, fileB, "app:\n  name: b\n")
+
+	a, err := Load(fileA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(fileB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.GetString("app.name") != "a" || b.GetString("app.name") != "b" {
+		t.Errorf("independent configs expected a/b, got %s/%s", a.GetString("app.name"), b.GetString("app.name"))
+	}
+}
+
+func TestEmptyConfig(t *testing.T) {
+	c := &Config{}
+	if len(c.Section("app")) != 0 {
+		t.Errorf("Section on empty config expected empty map")
+	}
+	if c.GetStringWithDefault("app.name", "fallback") != "fallback" {
+		t.Errorf("GetStringWithDefault on empty config expected 'fallback'")
+	}
+	if err := c.Reload(); err == nil {
+		t.Errorf("Reload on empty config expected error")
+	}
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Unmarshal 将整个默认配置解析到结构体
+func Unmarshal(out interface{}) error {
+	return Default().Unmarshal(out)
+}
+
+// UnmarshalKey 将默认配置指定路径下的值解析到结构体，见 Config.UnmarshalKey
+func UnmarshalKey(path string, out interface{}) error {
+	return Default().UnmarshalKey(path, out)
+}
+
+// Unmarshal 将整个配置解析到结构体
+func (c *Config) Unmarshal(out interface{}) error {
+	return decodeValue("", c.conf(), out)
+}
+
+// UnmarshalKey 将指定路径下的配置解析到结构体
+// 字段名取 yaml tag，未设置时为小写字段名；配置缺失时使用 default tag
+//
+//	type DBConfig struct {
+//		Host    string        `yaml:"host" default:"127.0.0.1"`
+//		MaxIdle int           `yaml:"max_idle" default:"10"`
+//		Timeout time.Duration `yaml:"timeout" default:"1s"`
+//	}
+//	var c DBConfig
+//	err := config.UnmarshalKey("database.test.master", &c)
+func (c *Config) UnmarshalKey(path string, out interface{}) error {
+	val, _ := c.getValueByPath(path)
+	return decodeValue(path, val, out)
+}
+
+func decodeValue(path string, in interface{}, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config: unmarshal target must be a non-nil pointer, got %T", out)
+	}
+
+	d := &decoder{}
+	d.decode(path, in, rv.Elem())
+	return errors.Join(d.errs...)
+}
+
+// decoder 将 yaml 解析出的通用结构（map/slice/标量）写入 Go 类型，收集所有字段错误
+type decoder struct {
+	errs []error
+}
+
+func (d *decoder) fail(path string, err error) {
+	if path == "" {
+		path = "(root)"
+	}
+	d.errs = append(d.errs, fmt.Errorf("config: %s: %w", path, err))
+}
+
+func (d *decoder) decode(path string, in interface{}, out reflect.Value) {
+	if in == nil {
+		// 缺失或 null 的值不覆盖已有值，但结构体仍需填充 default tag
+		switch {
+		case out.Kind() == reflect.Struct && out.Type() != timeType:
+			d.decodeStruct(path, map[string]interface{}{}, out)
+		case out.Kind() == reflect.Ptr && !out.IsNil():
+			d.decode(path, nil, out.Elem())
+		}
+		return
+	}
+
+	switch out.Type() {
+	case durationType:
+		v, err := toDurationE(in)
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		out.SetInt(int64(v))
+		return
+	case timeType:
+		v, err := toTimeE(in)
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		out.Set(reflect.ValueOf(v))
+		return
+	}
+
+	if out.CanAddr() && out.Addr().Type().Implements(textUnmarshalerType) {
+		if s, ok := in.(string); ok {
+			if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				d.fail(path, err)
+			}
+			return
+		}
+	}
+
+	switch out.Kind() {
+	case reflect.Ptr:
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		d.decode(path, in, out.Elem())
+	case reflect.Interface:
+		out.Set(reflect.ValueOf(in))
+	case reflect.Struct:
+		m, ok := in.(map[string]interface{})
+		if !ok {
+			d.fail(path, fmt.Errorf("cannot decode %T into %s", in, out.Type()))
+			return
+		}
+		d.decodeStruct(path, m, out)
+	case reflect.Map:
+		d.decodeMap(path, in, out)
+	case reflect.Slice:
+		d.decodeSlice(path, in, out)
+	case reflect.Array:
+		d.decodeArray(path, in, out)
+	case reflect.String:
+		v, err := toStringE(in)
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		out.SetString(v)
+	case reflect.Bool:
+		v, err := toBoolE(in)
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		out.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := toInt64E(in)
+		if err == nil && out.OverflowInt(v) {
+			err = fmt.Errorf("%d overflows %s", v, out.Type())
+		}
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		out.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v, err := toUint64E(in)
+		if err == nil && out.OverflowUint(v) {
+			err = fmt.Errorf("%d overflows %s", v, out.Type())
+		}
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		out.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := toFloat64E(in)
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		out.SetFloat(v)
+	default:
+		d.fail(path, fmt.Errorf("unsupported type %s", out.Type()))
+	}
+}
+
+func (d *decoder) decodeStruct(path string, in map[string]interface{}, out reflect.Value) {
+	t := out.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline, skip := fieldKey(field)
+		if skip {
+			continue
+		}
+		if inline {
+			d.decode(path, in, out.Field(i))
+			continue
+		}
+
+		fieldPath := joinPath(path, name)
+		val, ok := in[name]
+		if (!ok || val == nil) && field.Tag.Get("default") != "" {
+			val = parseDefault(field.Tag.Get("default"))
+		}
+		d.decode(fieldPath, val, out.Field(i))
+	}
+}
+
+func (d *decoder) decodeMap(path string, in interface{}, out reflect.Value) {
+	m, ok := in.(map[string]interface{})
+	if !ok {
+		d.fail(path, fmt.Errorf("cannot decode %T into %s", in, out.Type()))
+		return
+	}
+
+	t := out.Type()
+	if t.Key().Kind() != reflect.String {
+		d.fail(path, fmt.Errorf("unsupported map key type %s", t.Key()))
+		return
+	}
+	if out.IsNil() {
+		out.Set(reflect.MakeMapWithSize(t, len(m)))
+	}
+
+	for k, v := range m {
+		elem := reflect.New(t.Elem()).Elem()
+		d.decode(joinPath(path, k), v, elem)
+		out.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), elem)
+	}
+}
+
+func (d *decoder) decodeSlice(path string, in interface{}, out reflect.Value) {
+	items, ok := toItems(in)
+	if !ok {
+		d.fail(path, fmt.Errorf("cannot decode %T into %s", in, out.Type()))
+		return
+	}
+
+	s := reflect.MakeSlice(out.Type(), len(items), len(items))
+	for i, item := range items {
+		d.decode(joinPath(path, strconv.Itoa(i)), item, s.Index(i))
+	}
+	out.Set(s)
+}
+
+func (d *decoder) decodeArray(path string, in interface{}, out reflect.Value) {
+	items, ok := toItems(in)
+	if !ok {
+		d.fail(path, fmt.Errorf("cannot decode %T into %s", in, out.Type()))
+		return
+	}
+	if len(items) > out.Len() {
+		d.fail(path, fmt.Errorf("%d items exceed array length %d", len(items), out.Len()))
+		return
+	}
+
+	for i, item := range items {
+		d.decode(joinPath(path, strconv.Itoa(i)), item, out.Index(i))
+	}
+}
+
+// toItems 将数组配置转换为元素列表，逗号分隔的字符串（常见于环境变量）按列表处理
+func toItems(in interface{}) ([]interface{}, bool) {
+	switch v := in.(type) {
+	case []interface{}:
+		return v, true
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return []interface{}{}, true
+		}
+		parts := strings.Split(v, ",")
+		items := make([]interface{}, len(parts))
+		for i, p := range parts {
+			items[i] = strings.TrimSpace(p)
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// fieldKey 解析结构体字段对应的配置 key，规则与 yaml.v3 一致
+func fieldKey(field reflect.StructField) (name string, inline bool, skip bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			return "", true, false
+		}
+	}
+	if parts[0] != "" {
+		return parts[0], false, false
+	}
+	return strings.ToLower(field.Name), false, false
+}
+
+// parseDefault 按 YAML 语法解析 default tag，使 `default:"[a, b]"` 等写法也能生效
+func parseDefault(s string) interface{} {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil || v == nil {
+		return s
+	}
+	return v
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
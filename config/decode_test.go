@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDBConfig struct {
+	Drive    string `yaml:"drive"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	DB       string `yaml:"db"`
+	Charset  string `yaml:"charset" default:"utf8mb4"`
+	SSLMode  string `yaml:"ssl_mode" default:"disable"`
+	MaxIdle  int    `yaml:"max_idle" default:"2"`
+	MaxOpen  int    `yaml:"max_open"`
+}
+
+type testAppConfig struct {
+	App struct {
+		Name string `yaml:"name"`
+		Mode string `yaml:"mode"`
+	} `yaml:"app"`
+	Gorm struct {
+		TraceSQL      bool          `yaml:"trace_sql"`
+		SlowThreshold time.Duration `yaml:"slow_threshold"`
+		PrepareStmt   bool          `yaml:"prepare_stmt"`
+	} `yaml:"gorm"`
+	Database map[string]struct {
+		Master testDBConfig    `yaml:"master"`
+		Slaves []*testDBConfig `yaml:"slaves"`
+	} `yaml:"database"`
+	Timeout time.Duration `yaml:"timeout" default:"3s"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	os.Clearenv()
+	SetDefault(filepath.Join("testdata", "config.yaml"))
+
+	var c testAppConfig
+	if err := Unmarshal(&c); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+
+	if c.App.Name != "my-app" {
+		t.Errorf("app.name expected 'my-app', got '%s'", c.App.Name)
+	}
+	if c.Gorm.SlowThreshold != time.Second {
+		t.Errorf("gorm.slow_threshold expected 1s, got %v", c.Gorm.SlowThreshold)
+	}
+	if c.Timeout != 3*time.Second {
+		t.Errorf("timeout default expected 3s, got %v", c.Timeout)
+	}
+
+	db, ok := c.Database["test"]
+	if !ok {
+		t.Fatalf("database.test not decoded")
+	}
+	if db.Master.Host != "192.168.1.100" || db.Master.Port != 3306 {
+		t.Errorf("master expected 192.168.1.100:3306, got %s:%d", db.Master.Host, db.Master.Port)
+	}
+	if db.Master.Charset != "utf8" || db.Master.SSLMode != "disable" {
+		t.Errorf("master charset/ssl_mode expected utf8/disable, got %s/%s", db.Master.Charset, db.Master.SSLMode)
+	}
+	if len(db.Slaves) != 1 || db.Slaves[0].DB != "test2" || db.Slaves[0].MaxIdle != 2 {
+		t.Errorf("slaves not decoded as expected: %+v", db.Slaves)
+	}
+}
+
+func TestUnmarshalKey(t *testing.T) {
+	os.Clearenv()
+	SetDefault(filepath.Join("testdata", "config.yaml"))
+
+	var master testDBConfig
+	if err := UnmarshalKey("database.test.master", &master); err != nil {
+		t.Fatalf("UnmarshalKey error: %v", err)
+	}
+	if master.MaxIdle != 10 || master.MaxOpen != 20 {
+		t.Errorf("max_idle/max_open expected 10/20, got %d/%d", master.MaxIdle, master.MaxOpen)
+	}
+
+	// 缺失的路径只填充默认值
+	var missing testDBConfig
+	if err := UnmarshalKey("database.missing", &missing); err != nil {
+		t.Fatalf("UnmarshalKey on missing path error: %v", err)
+	}
+	if missing.Charset != "utf8mb4" || missing.Host != "" {
+		t.Errorf("missing path expected defaults only, got %+v", missing)
+	}
+}
+
+func TestUnmarshalKeyFieldError(t *testing.T) {
+	os.Clearenv()
+	SetDefault(filepath.Join("testdata", "config.yaml"))
+
+	var c struct {
+		Name    int  `yaml:"name"`
+		Addr    bool `yaml:"addr"`
+		Missing int  `yaml:"missing"`
+	}
+	err := UnmarshalKey("app", &c)
+	if err == nil {
+		t.Fatalf("expected type mismatch error")
+	}
+	for _, path := range []string{"app.name", "app.addr"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("error expected to contain %q, got %v", path, err)
+		}
+	}
+
+	if err := UnmarshalKey("app", c); err == nil {
+		t.Errorf("expected error for non-pointer target")
+	}
+}
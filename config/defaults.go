@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultOrigin 默认值的来源
+const DefaultOrigin = "default"
+
+var (
+	defaultsMu sync.RWMutex
+	defaults   []map[string]interface{}
+)
+
+// Defaults 注册默认值，优先级最低：命令行参数 > 环境变量 > 覆盖文件 > 基础文件 > 默认值
+// key 为配置路径，* 匹配已存在的一层 map key 或数组下标；值为 map 时只补充缺少的 key
+// 通常在各包的 init 中调用
+//
+//	config.Defaults(map[string]interface{}{
+//		"app.mode":                "release",
+//		"redis.*.db":              0,
+//		"database.*.master.charset": "utf8",
+//	})
+func Defaults(values map[string]interface{}) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	defaults = append(defaults, values)
+}
+
+// applyDefaults 为缺少的配置填充默认值
+func (l *loader) applyDefaults(root *yaml.Node) error {
+	defaultsMu.RLock()
+	registered := append([]map[string]interface{}(nil), defaults...)
+	defaultsMu.RUnlock()
+
+	for _, values := range registered {
+		// 按路径排序，保证结果稳定
+		paths := make([]string, 0, len(values))
+		for p := range values {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+
+		for _, p := range paths {
+			var val yaml.Node
+			if err := val.Encode(values[p]); err != nil {
+				return fmt.Errorf("config: default %s: %w", p, err)
+			}
+			l.fillDefault(root, strings.Split(p, "."), &val, false)
+		}
+	}
+	return nil
+}
+
+// fillDefault 沿路径创建缺少的 map 并写入默认值
+// * 只展开已存在的节点，* 之后只会为已存在的节点补充标量的最后一段
+// 值为 map 的默认值只合并到已存在的节点，如 database.*.master 不会为没有 master 的简单配置创建 master
+func (l *loader) fillDefault(node *yaml.Node, segments []string, val *yaml.Node, wild bool) {
+	seg, last := segments[0], len(segments) == 1
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if seg == "*" {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				l.fillChild(node, i+1, segments, val, true)
+			}
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == seg {
+				l.fillChild(node, i+1, segments, val, wild)
+				return
+			}
+		}
+		if wild && (!last || val.Kind == yaml.MappingNode) {
+			return
+		}
+		// 不存在的 key
+		next := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if last {
+			next = l.copyDefault(val)
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}
+		l.nodeFiles[key], l.nodeFiles[next] = DefaultOrigin, DefaultOrigin
+		node.Content = append(node.Content, key, next)
+		if !last {
+			l.fillDefault(next, segments[1:], val, wild)
+		}
+	case yaml.SequenceNode:
+		for i := range node.Content {
+			if seg == "*" || seg == strconv.Itoa(i) {
+				l.fillChild(node, i, segments, val, wild || seg == "*")
+			}
+		}
+	}
+}
+
+// fillChild 处理已存在的子节点：路径未结束时继续向下，结束时替换 null 或合并缺少的 key
+func (l *loader) fillChild(parent *yaml.Node, idx int, segments []string, val *yaml.Node, wild bool) {
+	child := parent.Content[idx]
+	if len(segments) > 1 {
+		l.fillDefault(child, segments[1:], val, wild)
+		return
+	}
+	if child.ShortTag() == "!!null" {
+		parent.Content[idx] = l.copyDefault(val)
+		return
+	}
+	l.fillMissing(child, val)
+}
+
+// fillMissing 双方都是 map 时将 src 中 dst 缺少或为 null 的 key 补充到 dst
+func (l *loader) fillMissing(dst, src *yaml.Node) {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, val := src.Content[i], src.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				if dst.Content[j+1].ShortTag() == "!!null" {
+					dst.Content[j+1] = l.copyDefault(val)
+				} else {
+					l.fillMissing(dst.Content[j+1], val)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.Content = append(dst.Content, l.copyDefault(key), l.copyDefault(val))
+		}
+	}
+}
+
+// copyDefault 深拷贝默认值节点，同一个默认值可能被写入多个位置
+func (l *loader) copyDefault(node *yaml.Node) *yaml.Node {
+	n := *node
+	n.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		n.Content[i] = l.copyDefault(child)
+	}
+	l.nodeFiles[&n] = DefaultOrigin
+	return &n
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	os.Clearenv()
+	defer func(saved []map[string]interface{}) {
+		defaultsMu.Lock()
+		defaults = saved
+		defaultsMu.Unlock()
+	}(defaults)
+	Defaults(map[string]interface{}{
+		"deftest.app.mode":          "release",
+		"deftest.app.port":          8080,
+		"deftest.db.*.charset":      "utf8",
+		"deftest.db.*.pool":         map[string]interface{}{"max_idle": 10, "max_open": 20},
+		"deftest.db.*.slaves.*.ssl": "disable",
+		"deftest.db.*.master.port":  3306,
+		"deftest.cache":             map[string]interface{}{"ttl": "1m"},
+	})
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, `
+deftest:
+  app:
+    mode: debug
+  db:
+    main:
+      charset: utf8mb4
+      pool:
+        max_open: 50
+      slaves:
+        - host: s1
+        - host: s2
+          ssl: require
+    empty:
+      charset:
+`)
+	os.Setenv("GUTILS_DEFTEST__DB__EXTRA__HOST", "db3")
+	defer os.Clearenv()
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	tests := map[string]interface{}{
+		"deftest.app.mode":              "debug",
+		"deftest.app.port":              8080,
+		"deftest.db.main.charset":       "utf8mb4",
+		"deftest.db.main.pool.max_open": 50,
+		"deftest.db.main.pool.max_idle": 10,
+		"deftest.db.main.slaves.0.ssl":  "disable",
+		"deftest.db.main.slaves.1.ssl":  "require",
+		"deftest.db.empty.charset":      "utf8",
+		"deftest.db.extra.charset":      "utf8",
+		"deftest.cache.ttl":             "1m",
+	}
+	for path, expected := range tests {
+		if got, _ := c.getValueByPath(path); got != expected {
+			t.Errorf("%s expected %#v, got %#v", path, expected, got)
+		}
+	}
+
+	if c.IsSet("deftest.db.*.charset") || c.IsSet("deftest.db.main.slaves.*") {
+		t.Errorf("wildcard default must not create literal * keys")
+	}
+	if c.IsSet("deftest.db.main.master") {
+		t.Errorf("wildcard default must not create intermediate keys")
+	}
+	if c.IsSet("deftest.db.empty.pool") || c.IsSet("deftest.db.extra.pool") {
+		t.Errorf("wildcard map default must not create missing keys")
+	}
+	if c.Origin("deftest.app.port") != DefaultOrigin {
+		t.Errorf("deftest.app.port origin expected %s, got %s", DefaultOrigin, c.Origin("deftest.app.port"))
+	}
+	if c.Origin("deftest.db.main.pool.max_idle") != DefaultOrigin {
+		t.Errorf("deftest.db.main.pool.max_idle origin expected %s, got %s", DefaultOrigin, c.Origin("deftest.db.main.pool.max_idle"))
+	}
+	if c.Origin("deftest.db.main.charset") != file {
+		t.Errorf("deftest.db.main.charset origin expected %s, got %s", file, c.Origin("deftest.db.main.charset"))
+	}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultEnvPrefix 环境变量覆盖配置的默认前缀
+	DefaultEnvPrefix = "GUTILS"
+	// DefaultEnvSeparator 环境变量中配置路径的默认分隔符
+	DefaultEnvSeparator = "__"
+)
+
+// Option 配置加载选项
+type Option func(*options)
+
+type options struct {
+	envPrefix    string
+	envSeparator string
+	// fsys 读取配置文件的文件系统，为空时读取磁盘
+	fsys fs.FS
+	// sources 远程配置来源
+	sources []sourceOption
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		envPrefix:    DefaultEnvPrefix,
+		envSeparator: DefaultEnvSeparator,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithEnvPrefix 设置环境变量覆盖的前缀，为空时不使用环境变量覆盖
+//
+//	GUTILS_DATABASE__TEST__MASTER__HOST=db2  =>  database.test.master.host: db2
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithEnvSeparator 设置环境变量中配置路径的分隔符
+func WithEnvSeparator(sep string) Option {
+	return func(o *options) {
+		if sep != "" {
+			o.envSeparator = sep
+		}
+	}
+}
+
+// WithFS 从 fsys（如 //go:embed 的 embed.FS）读取配置文件及其覆盖文件、include 片段
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// applyEnv 使用 <prefix>_ 开头的环境变量覆盖配置
+// 路径段不区分大小写地匹配已有 key，数字段匹配数组下标（等于数组长度时追加）
+// 值按已有值的类型转换，新增的值按 YAML 规则解析
+func (l *loader) applyEnv(root *yaml.Node, o options) error {
+	if o.envPrefix == "" {
+		return nil
+	}
+
+	prefix := o.envPrefix + "_"
+	var names []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		segments := strings.Split(name[len(prefix):], o.envSeparator)
+		if err := l.setValue(root, "env:"+name, segments, os.Getenv(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setValue 按路径段写入字符串值，origin 为值的来源，如 env:GUTILS_APP__MODE
+func (l *loader) setValue(root *yaml.Node, origin string, segments []string, value string) error {
+	node := root
+	for i, seg := range segments {
+		if seg == "" {
+			return nil
+		}
+		last := i == len(segments)-1
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			var next *yaml.Node
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if strings.EqualFold(node.Content[j].Value, seg) {
+					next = node.Content[j+1]
+					break
+				}
+			}
+			if next == nil {
+				next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				if last {
+					next = &yaml.Node{Kind: yaml.ScalarNode}
+				}
+				key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.ToLower(seg)}
+				node.Content = append(node.Content, key, next)
+				l.nodeFiles[key] = origin
+				l.nodeFiles[next] = origin
+			}
+			node = next
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx > len(node.Content) {
+				return fmt.Errorf("config: %s: invalid array index %q", origin, seg)
+			}
+			if idx == len(node.Content) {
+				next := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				if last {
+					next = &yaml.Node{Kind: yaml.ScalarNode}
+				}
+				node.Content = append(node.Content, next)
+				l.nodeFiles[next] = origin
+			}
+			node = node.Content[idx]
+		case yaml.ScalarNode:
+			if node.Tag != "!!null" {
+				return fmt.Errorf("config: %s: cannot set %q under scalar value", origin, seg)
+			}
+			// null 值可以被展开为 map
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			l.nodeFiles[node] = origin
+			return l.setValue(node, origin, segments[i:], value)
+		default:
+			return fmt.Errorf("config: %s: unsupported node at %q", origin, seg)
+		}
+	}
+
+	if err := coerceNode(node, value); err != nil {
+		return fmt.Errorf("config: %s: %w", origin, err)
+	}
+	l.markFile(node, origin)
+	return nil
+}
+
+// coerceNode 按节点原有类型写入字符串值
+func coerceNode(node *yaml.Node, value string) error {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		// map 与数组使用 YAML/JSON 字面量整体替换，如 [a, b] 或 {"k": "v"}
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(value), &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != node.Kind {
+			return fmt.Errorf("cannot convert %q to %s", value, node.ShortTag())
+		}
+		*node = *doc.Content[0]
+		return nil
+	}
+
+	scalar := yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	switch node.ShortTag() {
+	case "!!str":
+		scalar.Tag = "!!str"
+	case "!!int":
+		i, err := toInt64E(value)
+		if err != nil {
+			return err
+		}
+		scalar.Tag, scalar.Value = "!!int", strconv.FormatInt(i, 10)
+	case "!!float":
+		f, err := toFloat64E(value)
+		if err != nil {
+			return err
+		}
+		scalar.Tag, scalar.Value = "!!float", strconv.FormatFloat(f, 'g', -1, 64)
+	case "!!bool":
+		b, err := toBoolE(value)
+		if err != nil {
+			return err
+		}
+		scalar.Tag, scalar.Value = "!!bool", strconv.FormatBool(b)
+	default:
+		// 原值为 null 或新增的 key，按 YAML 规则推断类型
+	}
+	*node = scalar
+	return nil
+}
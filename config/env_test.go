@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvOverride(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("GUTILS_DATABASE__TEST__MASTER__HOST", "db2")
+	_ = os.Setenv("GUTILS_LOG__MAXSIZE", "64")
+	_ = os.Setenv("GUTILS_LOG__COMPRESS", "off")
+	_ = os.Setenv("GUTILS_APP__ADDR", "8081")
+	_ = os.Setenv("GUTILS_DATABASE__TEST__SLAVES__0__HOST", "slave1")
+	_ = os.Setenv("GUTILS_DATABASE__TEST__SLAVES__1__HOST", "slave2")
+	_ = os.Setenv("GUTILS_CACHE__SIZE", "16")
+	defer os.Clearenv()
+
+	c, err := Load(filepath.Join("testdata", "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	if got := c.GetString("database.test.master.host"); got != "db2" {
+		t.Errorf("database.test.master.host expected 'db2', got '%s'", got)
+	}
+	if got := c.Origin("database.test.master.host"); got != "env:GUTILS_DATABASE__TEST__MASTER__HOST" {
+		t.Errorf("origin expected env variable, got '%s'", got)
+	}
+	if got := c.GetInt("log.maxsize"); got != 64 {
+		t.Errorf("log.maxsize expected 64, got %d", got)
+	}
+	if c.GetBool("log.compress") {
+		t.Errorf("log.compress expected false")
+	}
+
+	// 原值为字符串时保持字符串类型
+	if val, _ := c.getValueByPath("app.addr"); val != "8081" {
+		t.Errorf("app.addr expected string '8081', got %T %v", val, val)
+	}
+
+	// 数组下标：覆盖已有元素、追加新元素
+	slaves := c.GetSlice("database.test.slaves")
+	if len(slaves) != 2 {
+		t.Fatalf("slaves expected 2 elements, got %d", len(slaves))
+	}
+	if got := c.GetString("database.test.slaves.0.host"); got != "slave1" {
+		t.Errorf("slaves.0.host expected 'slave1', got '%s'", got)
+	}
+	if got := c.GetString("database.test.slaves.0.db"); got != "test2" {
+		t.Errorf("slaves.0.db expected 'test2', got '%s'", got)
+	}
+	if got := c.GetString("database.test.slaves.1.host"); got != "slave2" {
+		t.Errorf("slaves.1.host expected 'slave2', got '%s'", got)
+	}
+
+	// 新增的 key 按 YAML 规则推断类型
+	if got := c.GetInt("cache.size"); got != 16 {
+		t.Errorf("cache.size expected 16, got %d", got)
+	}
+}
+
+func TestEnvOverrideOptions(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("MYAPP_LOG_MAXSIZE", "32")
+	_ = os.Setenv("GUTILS_LOG__MAXSIZE", "64")
+	defer os.Clearenv()
+
+	c, err := Load(filepath.Join("testdata", "config.yaml"), WithEnvPrefix("MYAPP"), WithEnvSeparator("_"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if got := c.GetInt("log.maxsize"); got != 32 {
+		t.Errorf("log.maxsize expected 32, got %d", got)
+	}
+
+	c, err = Load(filepath.Join("testdata", "config.yaml"), WithEnvPrefix(""))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if got := c.GetInt("log.maxsize"); got != 1024 {
+		t.Errorf("log.maxsize expected 1024 with env override disabled, got %d", got)
+	}
+}
+
+func TestEnvOverrideTypeError(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("GUTILS_LOG__MAXSIZE", "big")
+	defer os.Clearenv()
+
+	if _, err := Load(filepath.Join("testdata", "config.yaml")); err == nil {
+		t.Errorf("Load expected error for non-integer override")
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"flag"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+type flagBinding struct {
+	fs   *flag.FlagSet
+	name string
+	path string
+}
+
+var (
+	flagMu       sync.RWMutex
+	flagSets     []*flag.FlagSet
+	flagBindings []flagBinding
+)
+
+// BindFlags 使用 fs 中名称包含 . 的命令行参数覆盖同名配置，如 --app.mode=debug
+// 只有命令行中显式设置的参数生效，优先级高于环境变量；需要在 Init 之前调用 fs.Parse
+//
+//	flag.String("app.mode", "", "运行模式")
+//	flag.String("log.encode_type", "", "日志格式")
+//	flag.Parse()
+//	config.BindFlags(flag.CommandLine)
+//	config.SetDefault("conf/config.yaml")
+func BindFlags(fs *flag.FlagSet) {
+	flagMu.Lock()
+	defer flagMu.Unlock()
+	flagSets = append(flagSets, fs)
+}
+
+// BindFlag 将名称与配置路径不同的命令行参数绑定到配置路径，如 BindFlag(fs, "mode", "app.mode")
+func BindFlag(fs *flag.FlagSet, name, path string) {
+	flagMu.Lock()
+	defer flagMu.Unlock()
+	flagBindings = append(flagBindings, flagBinding{fs: fs, name: name, path: path})
+}
+
+// applyFlags 使用显式设置的命令行参数覆盖配置
+func (l *loader) applyFlags(root *yaml.Node) error {
+	flagMu.RLock()
+	sets := append([]*flag.FlagSet(nil), flagSets...)
+	bindings := append([]flagBinding(nil), flagBindings...)
+	flagMu.RUnlock()
+
+	for _, fs := range sets {
+		var err error
+		fs.Visit(func(f *flag.Flag) {
+			if err == nil && strings.Contains(f.Name, ".") {
+				err = l.setValue(root, "flag:"+f.Name, strings.Split(f.Name, "."), f.Value.String())
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, b := range bindings {
+		set := false
+		b.fs.Visit(func(f *flag.Flag) {
+			set = set || f.Name == b.name
+		})
+		if !set {
+			continue
+		}
+		if err := l.setValue(root, "flag:"+b.name, strings.Split(b.path, "."), b.fs.Lookup(b.name).Value.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
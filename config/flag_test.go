@@ -0,0 +1,81 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBindFlags(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("GUTILS_FLAGTEST__MODE", "env")
+	os.Setenv("GUTILS_FLAGTEST__LEVEL", "warn")
+	defer os.Clearenv()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("flagtest.mode", "", "")
+	fs.String("flagtest.encode", "json", "")
+	fs.Int("flagtest.port", 0, "")
+	fs.Bool("flagtest.debug", false, "")
+	fs.String("flagtest-name", "", "")
+	fs.String("config", "", "")
+	fs.String("level", "", "")
+	if err := fs.Parse([]string{"--flagtest.mode=flag", "--flagtest.port=9090", "--flagtest.debug", "--level=error", "--config=x.yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	defer resetFlags(flagSets, flagBindings)
+	BindFlags(fs)
+	BindFlag(fs, "level", "flagtest.level")
+	BindFlag(fs, "flagtest-name", "flagtest.name")
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "flagtest:\n  mode: file\n  encode: mis\n  port: 8080\n  debug: false\n")
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	tests := map[string]interface{}{
+		"flagtest.mode":   "flag", // flag > env > file
+		"flagtest.encode": "mis",  // 未显式设置的参数不生效
+		"flagtest.port":   9090,
+		"flagtest.debug":  true,
+		"flagtest.level":  "error",
+	}
+	for path, expected := range tests {
+		if got, _ := c.getValueByPath(path); got != expected {
+			t.Errorf("%s expected %#v, got %#v", path, expected, got)
+		}
+	}
+	if c.IsSet("flagtest.name") || c.IsSet("config") {
+		t.Errorf("unset or non-path flags must not be applied")
+	}
+	if c.Position("flagtest.mode") != "flag:flagtest.mode" {
+		t.Errorf("flagtest.mode position expected flag:flagtest.mode, got %s", c.Position("flagtest.mode"))
+	}
+}
+
+func TestBindFlagsInvalid(t *testing.T) {
+	os.Clearenv()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("flagbad.port", "", "")
+	if err := fs.Parse([]string{"--flagbad.port=abc"}); err != nil {
+		t.Fatal(err)
+	}
+	defer resetFlags(flagSets, flagBindings)
+	BindFlags(fs)
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "flagbad:\n  port: 8080\n")
+	if _, err := Load(file); err == nil || !strings.Contains(err.Error(), "flag:flagbad.port") {
+		t.Errorf("Load expected flag conversion error, got %v", err)
+	}
+}
+
+func resetFlags(sets []*flag.FlagSet, bindings []flagBinding) {
+	flagMu.Lock()
+	defer flagMu.Unlock()
+	flagSets, flagBindings = sets, bindings
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Unmarshaler 解析配置内容，签名与 json.Unmarshal、toml.Unmarshal 一致
+// v 为 *map[string]interface{}
+type Unmarshaler func(data []byte, v interface{}) error
+
+var (
+	formatMu sync.RWMutex
+	formats  = map[string]Unmarshaler{
+		"toml": toml.Unmarshal,
+	}
+)
+
+// RegisterFormat 注册配置格式，ext 为不带点的扩展名，如 "hcl"
+// yaml、yml 与 json 内置解析并保留行号，toml 内置；注册同名格式会覆盖内置实现
+//
+//	config.RegisterFormat("hcl", hcl.Unmarshal)
+func RegisterFormat(ext string, fn Unmarshaler) {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	formats[strings.ToLower(strings.TrimPrefix(ext, "."))] = fn
+}
+
+// formatOf 根据扩展名获取配置格式，无扩展名时按 yaml 处理
+func formatOf(file string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(file), "."))
+	if ext == "" {
+		return "yaml"
+	}
+	return ext
+}
+
+// parseContent 按格式解析配置内容，返回顶层 map 节点，name 仅用于错误信息
+// 环境变量在解析后按标量展开，见 interpolate
+func parseContent(name, format string, content []byte) (*yaml.Node, error) {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	formatMu.RLock()
+	fn, ok := formats[format]
+	formatMu.RUnlock()
+
+	var doc yaml.Node
+	switch {
+	case ok:
+		var m map[string]interface{}
+		if err := fn(content, &m); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if m == nil {
+			m = map[string]interface{}{}
+		}
+		if err := doc.Encode(m); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	case format == "yaml" || format == "yml" || format == "json":
+		// json 是 yaml 的子集，直接解析为 yaml 节点以保留行号
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format %q", name, format)
+	}
+
+	root := &doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	} else if doc.Kind == yaml.DocumentNode || doc.Kind == 0 {
+		// 空文件
+		root = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: top level must be a mapping", name)
+	}
+	return root, nil
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadJSON(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("JSON_HOST", "db1")
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	writeFile(t, file, `{
+  "app": {"name": "json-app", "mode": "test"},
+  "database": {"host": "${JSON_HOST:localhost}", "port": 3306}
+}`)
+	writeFile(t, filepath.Join(dir, "config.test.json"), `{"database": {"port": 3307}}`)
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if c.GetString("database.host") != "db1" {
+		t.Errorf("database.host expected db1, got %q", c.GetString("database.host"))
+	}
+	if c.GetInt("database.port") != 3307 {
+		t.Errorf("database.port expected overlay 3307, got %d", c.GetInt("database.port"))
+	}
+	if c.Position("app.name") != file+":2" {
+		t.Errorf("app.name position expected %s:2, got %s", file, c.Position("app.name"))
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("TOML_NAME", "toml-app")
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.toml")
+	writeFile(t, file, `
+include = ["redis.yaml"]
+
+[app]
+name = "${TOML_NAME}"
+debug = true
+
+[database.test]
+hosts = ["a", "b"]
+port = 3306
+`)
+	writeFile(t, filepath.Join(dir, "redis.yaml"), "redis:\n  default:\n    host: 127.0.0.1\n")
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if c.GetString("app.name") != "toml-app" || !c.GetBool("app.debug") {
+		t.Errorf("app unexpected: %v", c.Section("app"))
+	}
+	if c.GetInt("database.test.port") != 3306 || len(c.GetStringSlice("database.test.hosts")) != 2 {
+		t.Errorf("database unexpected: %v", c.Section("database"))
+	}
+	if c.GetString("redis.default.host") != "127.0.0.1" {
+		t.Errorf("include from toml expected redis.default.host")
+	}
+	if c.Origin("app.name") != file {
+		t.Errorf("app.name origin expected %s, got %s", file, c.Origin("app.name"))
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	os.Clearenv()
+	RegisterFormat(".testkv", func(data []byte, v interface{}) error {
+		m := map[string]interface{}{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			k, val, _ := strings.Cut(line, "=")
+			m[k] = val
+		}
+		return json.Unmarshal(mustJSON(t, m), v)
+	})
+
+	file := filepath.Join(t.TempDir(), "config.testkv")
+	writeFile(t, file, "name=kv\nport=${KV_PORT:8080}\n")
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if c.GetString("name") != "kv" || c.GetInt("port") != 8080 {
+		t.Errorf("registered format unexpected: %v", c.AllKeys())
+	}
+
+	unknown := filepath.Join(t.TempDir(), "config.ini")
+	writeFile(t, unknown, "a=1\n")
+	if _, err := Load(unknown); err == nil || !strings.Contains(err.Error(), "unsupported config format") {
+		t.Errorf("Load expected unsupported format error, got %v", err)
+	}
+}
+
+func TestLoadReader(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("READER_NAME", "from-reader")
+	c, err := LoadReader(strings.NewReader(`{"app": {"name": "${READER_NAME}"}}`), "json")
+	if err != nil {
+		t.Fatalf("LoadReader error: %v", err)
+	}
+	if c.GetString("app.name") != "from-reader" {
+		t.Errorf("app.name expected from-reader, got %q", c.GetString("app.name"))
+	}
+	if err := c.Reload(); err == nil {
+		t.Errorf("Reload expected error for reader config")
+	}
+
+	if _, err := LoadReader(strings.NewReader("a: [1"), "yaml"); err == nil {
+		t.Errorf("LoadReader expected error for bad yaml")
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	os.Clearenv()
+	fsys := fstest.MapFS{
+		"conf/config.yaml":       {Data: []byte("include: common/*.yaml\napp:\n  name: embedded\n  mode: prod\n")},
+		"conf/config.prod.yaml":  {Data: []byte("app:\n  debug: true\n")},
+		"conf/common/redis.yaml": {Data: []byte("redis:\n  default:\n    port: 6379\n")},
+	}
+
+	c, err := LoadFS(fsys, "conf/config.yaml")
+	if err != nil {
+		t.Fatalf("LoadFS error: %v", err)
+	}
+	if c.GetString("app.name") != "embedded" || !c.GetBool("app.debug") {
+		t.Errorf("app unexpected: %v", c.Section("app"))
+	}
+	if c.GetInt("redis.default.port") != 6379 {
+		t.Errorf("include from fs expected redis.default.port")
+	}
+	if c.Origin("app.debug") != "conf/config.prod.yaml" {
+		t.Errorf("app.debug origin expected conf/config.prod.yaml, got %s", c.Origin("app.debug"))
+	}
+	if err := c.Reload(); err != nil {
+		t.Errorf("Reload from fs error: %v", err)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("GUTILS_APP__NAME", "env-app")
+	os.Setenv("GUTILS_APP__PORT", "8080")
+
+	c, err := LoadEnv()
+	if err != nil {
+		t.Fatalf("LoadEnv error: %v", err)
+	}
+	if c.GetString("app.name") != "env-app" || c.GetInt("app.port") != 8080 {
+		t.Errorf("app unexpected: %v", c.Section("app"))
+	}
+	if c.Position("app.name") != "env:GUTILS_APP__NAME" {
+		t.Errorf("app.name position expected env:GUTILS_APP__NAME, got %s", c.Position("app.name"))
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// getE 获取配置值并转换类型，配置不存在或转换失败时返回带路径的错误
+func getE[T any](c *Config, path string, conv func(interface{}) (T, error)) (T, error) {
+	var zero T
+	val, ok := c.getValueByPath(path)
+	if !ok || val == nil {
+		return zero, fmt.Errorf("config: %s: not set", path)
+	}
+	v, err := conv(val)
+	if err != nil {
+		return zero, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// getWithDefault 获取配置值并转换类型，配置不存在或转换失败时返回默认值
+func getWithDefault[T any](c *Config, path string, defaultValue T, conv func(interface{}) (T, error)) T {
+	v, err := getE(c, path, conv)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+
+// mustGet 获取配置值并转换类型，配置不存在或转换失败时 panic
+func mustGet[T any](c *Config, path string, conv func(interface{}) (T, error)) T {
+	v, err := getE(c, path, conv)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func toUintE(val interface{}) (uint, error) {
+	u, err := toUint64E(val)
+	return uint(u), err
+}
+
+// GetDuration 通过路径获取时长配置值，字符串按 time.ParseDuration 解析（如 "1s"、"500ms"），数字按秒处理
+func (c *Config) GetDuration(path string) time.Duration {
+	return c.GetDurationWithDefault(path, 0)
+}
+
+// GetDurationWithDefault 通过路径获取时长配置值，不存在或无法转换时返回默认值
+func (c *Config) GetDurationWithDefault(path string, defaultValue time.Duration) time.Duration {
+	return getWithDefault(c, path, defaultValue, toDurationE)
+}
+
+// MustGetDuration 通过路径获取时长配置值，不存在或无法转换时 panic
+func (c *Config) MustGetDuration(path string) time.Duration {
+	return mustGet(c, path, toDurationE)
+}
+
+// GetFloat64 通过路径获取浮点数配置值
+func (c *Config) GetFloat64(path string) float64 {
+	return c.GetFloat64WithDefault(path, 0)
+}
+
+// GetFloat64WithDefault 通过路径获取浮点数配置值，不存在或无法转换时返回默认值
+func (c *Config) GetFloat64WithDefault(path string, defaultValue float64) float64 {
+	return getWithDefault(c, path, defaultValue, toFloat64E)
+}
+
+// MustGetFloat64 通过路径获取浮点数配置值，不存在或无法转换时 panic
+func (c *Config) MustGetFloat64(path string) float64 {
+	return mustGet(c, path, toFloat64E)
+}
+
+// GetInt64 通过路径获取 int64 配置值
+func (c *Config) GetInt64(path string) int64 {
+	return c.GetInt64WithDefault(path, 0)
+}
+
+// GetInt64WithDefault 通过路径获取 int64 配置值，不存在或无法转换时返回默认值
+func (c *Config) GetInt64WithDefault(path string, defaultValue int64) int64 {
+	return getWithDefault(c, path, defaultValue, toInt64TruncE)
+}
+
+// MustGetInt64 通过路径获取 int64 配置值，不存在或无法转换时 panic
+func (c *Config) MustGetInt64(path string) int64 {
+	return mustGet(c, path, toInt64E)
+}
+
+// GetUint 通过路径获取无符号整数配置值，负数视为无效
+func (c *Config) GetUint(path string) uint {
+	return c.GetUintWithDefault(path, 0)
+}
+
+// GetUintWithDefault 通过路径获取无符号整数配置值，不存在或无法转换时返回默认值
+func (c *Config) GetUintWithDefault(path string, defaultValue uint) uint {
+	return getWithDefault(c, path, defaultValue, toUintE)
+}
+
+// MustGetUint 通过路径获取无符号整数配置值，不存在或无法转换时 panic
+func (c *Config) MustGetUint(path string) uint {
+	return mustGet(c, path, toUintE)
+}
+
+// GetStringSlice 通过路径获取字符串数组配置值，字符串按逗号分隔，如 "a,b,c"
+func (c *Config) GetStringSlice(path string) []string {
+	return c.GetStringSliceWithDefault(path, nil)
+}
+
+// GetStringSliceWithDefault 通过路径获取字符串数组配置值，不存在或无法转换时返回默认值
+func (c *Config) GetStringSliceWithDefault(path string, defaultValue []string) []string {
+	return getWithDefault(c, path, defaultValue, toStringSliceE)
+}
+
+// MustGetStringSlice 通过路径获取字符串数组配置值，不存在或无法转换时 panic
+func (c *Config) MustGetStringSlice(path string) []string {
+	return mustGet(c, path, toStringSliceE)
+}
+
+// GetStringMapString 通过路径获取 map[string]string 配置值，value 转换为字符串
+func (c *Config) GetStringMapString(path string) map[string]string {
+	return c.GetStringMapStringWithDefault(path, nil)
+}
+
+// GetStringMapStringWithDefault 通过路径获取 map[string]string 配置值，不存在或无法转换时返回默认值
+func (c *Config) GetStringMapStringWithDefault(path string, defaultValue map[string]string) map[string]string {
+	return getWithDefault(c, path, defaultValue, toStringMapStringE)
+}
+
+// MustGetStringMapString 通过路径获取 map[string]string 配置值，不存在或无法转换时 panic
+func (c *Config) MustGetStringMapString(path string) map[string]string {
+	return mustGet(c, path, toStringMapStringE)
+}
+
+// GetTime 通过路径获取时间配置值，支持 RFC3339、"2006-01-02 15:04:05"、"2006-01-02" 及 Unix 时间戳
+func (c *Config) GetTime(path string) time.Time {
+	return c.GetTimeWithDefault(path, time.Time{})
+}
+
+// GetTimeWithDefault 通过路径获取时间配置值，不存在或无法转换时返回默认值
+func (c *Config) GetTimeWithDefault(path string, defaultValue time.Time) time.Time {
+	return getWithDefault(c, path, defaultValue, toTimeE)
+}
+
+// MustGetTime 通过路径获取时间配置值，不存在或无法转换时 panic
+func (c *Config) MustGetTime(path string) time.Time {
+	return mustGet(c, path, toTimeE)
+}
+
+// GetSizeInBytes 通过路径获取容量配置值的字节数，如 "512MB"、"1.5g"、"1024"，单位按 1024 进制
+func (c *Config) GetSizeInBytes(path string) int64 {
+	return c.GetSizeInBytesWithDefault(path, 0)
+}
+
+// GetSizeInBytesWithDefault 通过路径获取容量配置值的字节数，不存在或无法转换时返回默认值
+func (c *Config) GetSizeInBytesWithDefault(path string, defaultValue int64) int64 {
+	return getWithDefault(c, path, defaultValue, toSizeInBytesE)
+}
+
+// MustGetSizeInBytes 通过路径获取容量配置值的字节数，不存在或无法转换时 panic
+func (c *Config) MustGetSizeInBytes(path string) int64 {
+	return mustGet(c, path, toSizeInBytesE)
+}
+
+// GetDuration 通过路径获取默认配置中的时长配置值
+func GetDuration(path string) time.Duration {
+	return Default().GetDuration(path)
+}
+
+// GetDurationWithDefault 通过路径获取默认配置中的时长配置值，带默认值
+func GetDurationWithDefault(path string, defaultValue time.Duration) time.Duration {
+	return Default().GetDurationWithDefault(path, defaultValue)
+}
+
+// MustGetDuration 通过路径获取默认配置中的时长配置值，不存在或无法转换时 panic
+func MustGetDuration(path string) time.Duration {
+	return Default().MustGetDuration(path)
+}
+
+// GetFloat64 通过路径获取默认配置中的浮点数配置值
+func GetFloat64(path string) float64 {
+	return Default().GetFloat64(path)
+}
+
+// GetFloat64WithDefault 通过路径获取默认配置中的浮点数配置值，带默认值
+func GetFloat64WithDefault(path string, defaultValue float64) float64 {
+	return Default().GetFloat64WithDefault(path, defaultValue)
+}
+
+// MustGetFloat64 通过路径获取默认配置中的浮点数配置值，不存在或无法转换时 panic
+func MustGetFloat64(path string) float64 {
+	return Default().MustGetFloat64(path)
+}
+
+// GetInt64 通过路径获取默认配置中的 int64 配置值
+func GetInt64(path string) int64 {
+	return Default().GetInt64(path)
+}
+
+// GetInt64WithDefault 通过路径获取默认配置中的 int64 配置值，带默认值
+func GetInt64WithDefault(path string, defaultValue int64) int64 {
+	return Default().GetInt64WithDefault(path, defaultValue)
+}
+
+// MustGetInt64 通过路径获取默认配置中的 int64 配置值，不存在或无法转换时 panic
+func MustGetInt64(path string) int64 {
+	return Default().MustGetInt64(path)
+}
+
+// GetUint 通过路径获取默认配置中的无符号整数配置值
+func GetUint(path string) uint {
+	return Default().GetUint(path)
+}
+
+// GetUintWithDefault 通过路径获取默认配置中的无符号整数配置值，带默认值
+func GetUintWithDefault(path string, defaultValue uint) uint {
+	return Default().GetUintWithDefault(path, defaultValue)
+}
+
+// MustGetUint 通过路径获取默认配置中的无符号整数配置值，不存在或无法转换时 panic
+func MustGetUint(path string) uint {
+	return Default().MustGetUint(path)
+}
+
+// GetStringSlice 通过路径获取默认配置中的字符串数组配置值
+func GetStringSlice(path string) []string {
+	return Default().GetStringSlice(path)
+}
+
+// GetStringSliceWithDefault 通过路径获取默认配置中的字符串数组配置值，带默认值
+func GetStringSliceWithDefault(path string, defaultValue []string) []string {
+	return Default().GetStringSliceWithDefault(path, defaultValue)
+}
+
+// MustGetStringSlice 通过路径获取默认配置中的字符串数组配置值，不存在或无法转换时 panic
+func MustGetStringSlice(path string) []string {
+	return Default().MustGetStringSlice(path)
+}
+
+// GetStringMapString 通过路径获取默认配置中的 map[string]string 配置值
+func GetStringMapString(path string) map[string]string {
+	return Default().GetStringMapString(path)
+}
+
+// GetStringMapStringWithDefault 通过路径获取默认配置中的 map[string]string 配置值，带默认值
+func GetStringMapStringWithDefault(path string, defaultValue map[string]string) map[string]string {
+	return Default().GetStringMapStringWithDefault(path, defaultValue)
+}
+
+// MustGetStringMapString 通过路径获取默认配置中的 map[string]string 配置值，不存在或无法转换时 panic
+func MustGetStringMapString(path string) map[string]string {
+	return Default().MustGetStringMapString(path)
+}
+
+// GetTime 通过路径获取默认配置中的时间配置值
+func GetTime(path string) time.Time {
+	return Default().GetTime(path)
+}
+
+// GetTimeWithDefault 通过路径获取默认配置中的时间配置值，带默认值
+func GetTimeWithDefault(path string, defaultValue time.Time) time.Time {
+	return Default().GetTimeWithDefault(path, defaultValue)
+}
+
+// MustGetTime 通过路径获取默认配置中的时间配置值，不存在或无法转换时 panic
+func MustGetTime(path string) time.Time {
+	return Default().MustGetTime(path)
+}
+
+// GetSizeInBytes 通过路径获取默认配置中的容量配置值的字节数
+func GetSizeInBytes(path string) int64 {
+	return Default().GetSizeInBytes(path)
+}
+
+// GetSizeInBytesWithDefault 通过路径获取默认配置中的容量配置值的字节数，带默认值
+func GetSizeInBytesWithDefault(path string, defaultValue int64) int64 {
+	return Default().GetSizeInBytesWithDefault(path, defaultValue)
+}
+
+// MustGetSizeInBytes 通过路径获取默认配置中的容量配置值的字节数，不存在或无法转换时 panic
+func MustGetSizeInBytes(path string) int64 {
+	return Default().MustGetSizeInBytes(path)
+}
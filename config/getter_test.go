@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadGetterConfig(t *testing.T) *Config {
+	os.Clearenv()
+	os.Setenv("FLAG", "true")
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, `
+app:
+  debug: ${FLAG:false}
+  port: "8080"
+  ratio: "0.75"
+  timeout: 1500ms
+  idle: 30
+  offset: -5
+  hosts: a, b ,c
+  tags: [x, 1, true]
+  labels:
+    team: infra
+    replicas: 3
+  start: "2024-01-02 03:04:05"
+  day: 2024-01-02
+  buffer: 512MB
+  chunk: 1.5k
+  raw: 4096
+`)
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	return c
+}
+
+func TestTypedGetters(t *testing.T) {
+	c := loadGetterConfig(t)
+
+	if !c.GetBool("app.debug") {
+		t.Errorf("GetBool expected true for expanded string")
+	}
+	if c.GetInt("app.port") != 8080 {
+		t.Errorf("GetInt expected 8080, got %d", c.GetInt("app.port"))
+	}
+	if c.GetInt64("app.offset") != -5 {
+		t.Errorf("GetInt64 expected -5, got %d", c.GetInt64("app.offset"))
+	}
+	if c.GetFloat64("app.ratio") != 0.75 {
+		t.Errorf("GetFloat64 expected 0.75, got %v", c.GetFloat64("app.ratio"))
+	}
+	if c.GetUint("app.port") != 8080 {
+		t.Errorf("GetUint expected 8080, got %d", c.GetUint("app.port"))
+	}
+	if c.GetDuration("app.timeout") != 1500*time.Millisecond {
+		t.Errorf("GetDuration expected 1.5s, got %v", c.GetDuration("app.timeout"))
+	}
+	if c.GetDuration("app.idle") != 30*time.Second {
+		t.Errorf("GetDuration expected 30s for number, got %v", c.GetDuration("app.idle"))
+	}
+	if got := c.GetStringSlice("app.hosts"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("GetStringSlice expected [a b c], got %v", got)
+	}
+	if got := c.GetStringSlice("app.tags"); !reflect.DeepEqual(got, []string{"x", "1", "true"}) {
+		t.Errorf("GetStringSlice expected [x 1 true], got %v", got)
+	}
+	if got := c.GetStringMapString("app.labels"); !reflect.DeepEqual(got, map[string]string{"team": "infra", "replicas": "3"}) {
+		t.Errorf("GetStringMapString unexpected %v", got)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local); !c.GetTime("app.start").Equal(want) {
+		t.Errorf("GetTime expected %v, got %v", want, c.GetTime("app.start"))
+	}
+	if c.GetTime("app.day").Day() != 2 {
+		t.Errorf("GetTime expected date, got %v", c.GetTime("app.day"))
+	}
+	if c.GetSizeInBytes("app.buffer") != 512<<20 {
+		t.Errorf("GetSizeInBytes expected 512MB, got %d", c.GetSizeInBytes("app.buffer"))
+	}
+	if c.GetSizeInBytes("app.chunk") != 1536 {
+		t.Errorf("GetSizeInBytes expected 1536, got %d", c.GetSizeInBytes("app.chunk"))
+	}
+	if c.GetSizeInBytes("app.raw") != 4096 {
+		t.Errorf("GetSizeInBytes expected 4096, got %d", c.GetSizeInBytes("app.raw"))
+	}
+}
+
+func TestTypedGettersDefault(t *testing.T) {
+	c := loadGetterConfig(t)
+
+	if c.GetDurationWithDefault("app.missing", time.Minute) != time.Minute {
+		t.Errorf("GetDurationWithDefault expected default for missing key")
+	}
+	if c.GetUintWithDefault("app.offset", 7) != 7 {
+		t.Errorf("GetUintWithDefault expected default for negative value")
+	}
+	if c.GetFloat64WithDefault("app.hosts", 1.5) != 1.5 {
+		t.Errorf("GetFloat64WithDefault expected default for invalid value")
+	}
+	if c.GetBoolWithDefault("app.missing", true) != true {
+		t.Errorf("GetBoolWithDefault expected default for missing key")
+	}
+	if c.GetIntWithDefault("app.labels", 3) != 3 {
+		t.Errorf("GetIntWithDefault expected default for map value")
+	}
+	if c.GetSizeInBytesWithDefault("app.timeout", 1) != 1 {
+		t.Errorf("GetSizeInBytesWithDefault expected default for invalid size")
+	}
+	if got := c.GetStringSlice("app.missing"); got != nil {
+		t.Errorf("GetStringSlice expected nil for missing key, got %v", got)
+	}
+}
+
+func TestMustGet(t *testing.T) {
+	c := loadGetterConfig(t)
+
+	if c.MustGetInt64("app.port") != 8080 {
+		t.Errorf("MustGetInt64 expected 8080")
+	}
+
+	for _, fn := range []func(){
+		func() { c.MustGetDuration("app.missing") },
+		func() { c.MustGetTime("app.hosts") },
+		func() { c.MustGetStringMapString("app.hosts") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MustGet expected panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestIntFraction(t *testing.T) {
+	c, err := LoadReader(strings.NewReader("app:\n  ratio: 1.5\n  share: \"1.9\"\n  whole: 2.0\n"), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GetInt 系列截断小数
+	if c.GetInt("app.ratio") != 1 || c.GetInt64("app.share") != 1 || c.GetIntWithDefault("app.whole", 7) != 2 {
+		t.Errorf("GetInt expected truncation, got %d %d", c.GetInt("app.ratio"), c.GetInt64("app.share"))
+	}
+
+	// 解码与 Must 系列要求整数
+	for _, path := range []string{"app.ratio", "app.share"} {
+		var out int
+		if err := c.UnmarshalKey(path, &out); err == nil {
+			t.Errorf("UnmarshalKey %s expected error, got %d", path, out)
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MustGetInt64 %s expected panic", path)
+				}
+			}()
+			c.MustGetInt64(path)
+		}()
+	}
+	var whole int
+	if err := c.UnmarshalKey("app.whole", &whole); err != nil || whole != 2 || c.MustGetInt64("app.whole") != 2 {
+		t.Errorf("expected 2 for app.whole, got %d %v", whole, err)
+	}
+}
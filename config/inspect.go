@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsSet 判断配置中是否存在该路径，值为空（如 key: 或 key: ""）也视为已设置
+func (c *Config) IsSet(path string) bool {
+	_, ok := c.getValueByPath(path)
+	return ok
+}
+
+// AllKeys 返回全部叶子配置的路径（已排序），数组与空 map 视为叶子
+func (c *Config) AllKeys() []string {
+	var keys []string
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			path := joinPath(prefix, k)
+			if sub, ok := v.(map[string]interface{}); ok && len(sub) > 0 {
+				walk(path, sub)
+				continue
+			}
+			keys = append(keys, path)
+		}
+	}
+	walk("", c.conf())
+	sort.Strings(keys)
+	return keys
+}
+
+// Sub 返回以 path 为根的配置视图，路径不存在或不是 map 时返回空配置
+// 视图是调用时的快照，不随 Reload 更新；来源位置与敏感值标记会一并保留
+func (c *Config) Sub(path string) *Config {
+	sub := &Config{file: c.file, opts: c.opts}
+	val, _ := c.getValueByPath(path)
+	data, ok := val.(map[string]interface{})
+	if !ok {
+		return sub
+	}
+
+	st := c.state()
+	prefix := path + "."
+	origins := map[string]origin{}
+	for p, o := range st.origins {
+		if strings.HasPrefix(p, prefix) {
+			origins[p[len(prefix):]] = o
+		}
+	}
+
+	secrets := map[string]bool{}
+	var mark func(rel string, val interface{})
+	mark = func(rel string, val interface{}) {
+		if rel != "" && c.IsSecret(joinPath(path, rel)) {
+			secrets[rel] = true
+			return
+		}
+		switch v := val.(type) {
+		case map[string]interface{}:
+			for k, item := range v {
+				mark(joinPath(rel, k), item)
+			}
+		case []interface{}:
+			for i, item := range v {
+				mark(joinPath(rel, strconv.Itoa(i)), item)
+			}
+		}
+	}
+	mark("", data)
+
+	sub.st.Store(&state{data: data, origins: origins, files: st.files, secrets: secrets})
+	return sub
+}
+
+// Dump 将合并后的生效配置以 yaml 或 json 格式写入 w，敏感值已脱敏
+func (c *Config) Dump(w io.Writer, format string) error {
+	data := c.Redacted()
+	switch strings.ToLower(format) {
+	case "", "yaml", "yml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	default:
+		return fmt.Errorf("config: unsupported dump format %q", format)
+	}
+}
+
+// IsSet 判断默认配置中是否存在该路径
+func IsSet(path string) bool {
+	return Default().IsSet(path)
+}
+
+// AllKeys 返回默认配置全部叶子配置的路径
+func AllKeys() []string {
+	return Default().AllKeys()
+}
+
+// Sub 返回默认配置以 path 为根的配置视图
+func Sub(path string) *Config {
+	return Default().Sub(path)
+}
+
+// Dump 将默认配置脱敏后以 yaml 或 json 格式写入 w
+func Dump(w io.Writer, format string) error {
+	return Default().Dump(w, format)
+}
+
+// DumpHandler 输出默认配置的 http.Handler，通过 ?format=json 选择格式，默认 yaml
+// pprof.HttpServer 已挂载在 <prefix>/config
+func DumpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if strings.EqualFold(format, "json") {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		if err := Dump(w, format); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	})
+}
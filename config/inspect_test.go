@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func loadInspectConfig(t *testing.T) *Config {
+	os.Clearenv()
+	os.Setenv("INSPECT_PASS", "s3cret")
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, `
+app:
+  name: demo
+  empty: ""
+  none:
+db:
+  main:
+    host: 127.0.0.1
+    password: ${env:INSPECT_PASS}
+  hosts: [a, b]
+  extra: {}
+`)
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	return c
+}
+
+func TestIsSet(t *testing.T) {
+	c := loadInspectConfig(t)
+
+	for _, path := range []string{"app.name", "app.empty", "app.none", "db.hosts.1", "db.extra"} {
+		if !c.IsSet(path) {
+			t.Errorf("IsSet(%s) expected true", path)
+		}
+	}
+	for _, path := range []string{"app.missing", "db.hosts.2", "app.name.x"} {
+		if c.IsSet(path) {
+			t.Errorf("IsSet(%s) expected false", path)
+		}
+	}
+}
+
+func TestAllKeys(t *testing.T) {
+	c := loadInspectConfig(t)
+
+	want := []string{"app.empty", "app.name", "app.none", "db.extra", "db.hosts", "db.main.host", "db.main.password"}
+	if got := c.AllKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllKeys expected %v, got %v", want, got)
+	}
+}
+
+func TestSub(t *testing.T) {
+	c := loadInspectConfig(t)
+
+	sub := c.Sub("db.main")
+	if sub.GetString("host") != "127.0.0.1" {
+		t.Errorf("Sub host expected 127.0.0.1, got %q", sub.GetString("host"))
+	}
+	if !sub.IsSecret("password") {
+		t.Errorf("Sub expected password to stay secret")
+	}
+	if sub.Position("host") != c.Position("db.main.host") {
+		t.Errorf("Sub position expected %s, got %s", c.Position("db.main.host"), sub.Position("host"))
+	}
+
+	if keys := c.Sub("app.name").AllKeys(); len(keys) != 0 {
+		t.Errorf("Sub of scalar expected empty config, got %v", keys)
+	}
+	if keys := c.Sub("missing").AllKeys(); len(keys) != 0 {
+		t.Errorf("Sub of missing path expected empty config, got %v", keys)
+	}
+}
+
+func TestDump(t *testing.T) {
+	c := loadInspectConfig(t)
+
+	var buf bytes.Buffer
+	if err := c.Dump(&buf, "yaml"); err != nil {
+		t.Fatalf("Dump yaml error: %v", err)
+	}
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("Dump yaml leaked secret:\n%s", buf.String())
+	}
+	var fromYAML map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("Dump yaml output invalid: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, c.Redacted()) {
+		t.Errorf("Dump yaml expected %v, got %v", c.Redacted(), fromYAML)
+	}
+
+	buf.Reset()
+	if err := c.Dump(&buf, "json"); err != nil {
+		t.Fatalf("Dump json error: %v", err)
+	}
+	var fromJSON map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("Dump json output invalid: %v", err)
+	}
+	if fromJSON["db"].(map[string]interface{})["main"].(map[string]interface{})["password"] != RedactedValue {
+		t.Errorf("Dump json expected redacted password, got %s", buf.String())
+	}
+
+	if err := c.Dump(&buf, "xml"); err == nil {
+		t.Errorf("Dump expected error for unsupported format")
+	}
+}
+
+func TestDumpHandler(t *testing.T) {
+	c := loadInspectConfig(t)
+	old := Default()
+	SetDefaultConfig(c)
+	defer SetDefaultConfig(old)
+
+	rec := httptest.NewRecorder()
+	DumpHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/pprof/config?format=json", nil))
+	if rec.Code != 200 || !strings.Contains(rec.Body.String(), `"name": "demo"`) {
+		t.Errorf("DumpHandler unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	DumpHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/pprof/config?format=xml", nil))
+	if rec.Code != 400 {
+		t.Errorf("DumpHandler expected 400 for unsupported format, got %d", rec.Code)
+	}
+}
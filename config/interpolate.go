@@ -0,0 +1,283 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigRef 引用其他配置项的前缀，如 ${config:app.name}
+const ConfigRef = "config"
+
+// 插值语法，只作用于标量值（不含 key 与注释）：
+//
+//	${VAR}            环境变量，未设置时为空
+//	${VAR:-default}   未设置或为空时使用 default
+//	${VAR-default}    未设置时使用 default
+//	${VAR:default}    同 ${VAR:-default}（兼容旧写法）
+//	${VAR:?message}   未设置或为空时报错
+//	${VAR?message}    未设置时报错
+//	${scheme:ref}     密钥引用，见 RegisterSecretProvider
+//	${config:path}    引用其他配置项，在全部覆盖层与环境变量合并后解析
+//	$$                字面量 $
+//
+// default 中可以继续嵌套 ${...}，只在用到时才会展开；单独的 $ 保持原样
+
+// interpolate 展开文件中标量值的环境变量与密钥引用，含 ${config:...} 的值在合并后处理
+func (l *loader) interpolate(root *yaml.Node, file string) error {
+	var err error
+	walkNodes(root, "", func(_ string, node *yaml.Node) {
+		if err != nil || node.Kind != yaml.ScalarNode || !strings.Contains(node.Value, "$") {
+			return
+		}
+		if strings.Contains(node.Value, "${"+ConfigRef+":") {
+			l.deferred[node] = true
+			return
+		}
+		err = l.expandNode(node, file, nil)
+	})
+	return err
+}
+
+// resolveRefs 解析合并后配置中的 ${config:path} 引用
+func (l *loader) resolveRefs(root *yaml.Node) error {
+	if len(l.deferred) == 0 {
+		return nil
+	}
+
+	resolving := map[*yaml.Node]bool{}
+	var resolve func(node *yaml.Node) error
+	resolve = func(node *yaml.Node) error {
+		if !l.deferred[node] {
+			return nil
+		}
+		file := l.nodeFiles[node]
+		if resolving[node] {
+			return fmt.Errorf("%s:%d: reference cycle in %q", file, node.Line, node.Value)
+		}
+		resolving[node] = true
+		defer delete(resolving, node)
+
+		ref := func(path string) (*yaml.Node, error) {
+			target := nodeAt(root, path)
+			if target == nil {
+				return nil, fmt.Errorf("%s is not set", path)
+			}
+			if err := resolve(target); err != nil {
+				return nil, err
+			}
+			if l.secretNodes[target] {
+				l.secretNodes[node] = true
+			}
+			return target, nil
+		}
+
+		// 整个值只有一个引用时复制被引用的节点，保留其类型，可以引用 map 与数组
+		if expr := node.Value; strings.HasPrefix(expr, "${"+ConfigRef+":") && matchBrace(expr, 1) == len(expr)-1 {
+			target, err := ref(expr[len(ConfigRef)+3 : len(expr)-1])
+			if err != nil {
+				return fmt.Errorf("%s:%d: %s: %w", file, node.Line, expr, err)
+			}
+			line, col := node.Line, node.Column
+			*node = *target
+			node.Line, node.Column = line, col
+			delete(l.deferred, node)
+			return nil
+		}
+
+		if err := l.expandNode(node, file, ref); err != nil {
+			return err
+		}
+		delete(l.deferred, node)
+		return nil
+	}
+
+	var err error
+	walkNodes(root, "", func(_ string, node *yaml.Node) {
+		if err == nil {
+			err = resolve(node)
+		}
+	})
+	return err
+}
+
+// expandNode 展开单个标量，未加引号且未显式声明类型的值按展开结果重新推断类型
+// 展开为空时保持空字符串，不会变为 null
+func (l *loader) expandNode(node *yaml.Node, file string, ref func(path string) (*yaml.Node, error)) error {
+	in := &interpolator{ref: ref}
+	value, err := in.expand(node.Value)
+	if err != nil {
+		return fmt.Errorf("%s:%d: %w", file, node.Line, err)
+	}
+
+	node.Value = value
+	switch {
+	case in.secret:
+		node.Tag, node.Style = "!!str", 0
+		l.secretNodes[node] = true
+	case value == "":
+		node.Tag = "!!str"
+	case node.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0:
+		node.Tag = ""
+	}
+	return nil
+}
+
+type interpolator struct {
+	// ref 查找被引用的配置项，为空时不支持 ${config:...}
+	ref func(path string) (*yaml.Node, error)
+	// secret 是否使用了密钥引用
+	secret bool
+}
+
+// expand 展开字符串中的全部 ${...} 与 $$
+func (in *interpolator) expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := matchBrace(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unclosed %q", s[i:])
+			}
+			val, err := in.eval(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(val)
+			i = end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// eval 计算 ${...} 中的表达式
+func (in *interpolator) eval(expr string) (string, error) {
+	if path, ok := strings.CutPrefix(expr, ConfigRef+":"); ok {
+		return in.evalRef(path)
+	}
+	if fn, ref, ok := splitSecretRef(expr); ok {
+		secret, err := fn(ref)
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", expr, err)
+		}
+		in.secret = true
+		return secret, nil
+	}
+
+	n := 0
+	for n < len(expr) && isNameChar(expr[n]) {
+		n++
+	}
+	name, op := expr[:n], expr[n:]
+	if name == "" {
+		return "", fmt.Errorf("invalid expression ${%s}", expr)
+	}
+
+	val, set := os.LookupEnv(name)
+	if op == "" {
+		return val, nil
+	}
+
+	// 带 : 的形式把空值视为未设置
+	emptyIsUnset := op[0] == ':'
+	rest := strings.TrimPrefix(op, ":")
+	if set && (val != "" || !emptyIsUnset) {
+		return val, nil
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "?"):
+		msg, err := in.expand(rest[1:])
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			msg = "is required"
+		}
+		return "", fmt.Errorf("%s: %s", name, msg)
+	case strings.HasPrefix(rest, "-"):
+		return in.expand(rest[1:])
+	case emptyIsUnset:
+		return in.expand(rest)
+	default:
+		return "", fmt.Errorf("invalid expression ${%s}", expr)
+	}
+}
+
+// evalRef 将被引用的标量配置项作为字符串代入
+func (in *interpolator) evalRef(path string) (string, error) {
+	if in.ref == nil {
+		return "", fmt.Errorf("${%s:%s} is not supported here", ConfigRef, path)
+	}
+	target, err := in.ref(path)
+	if err != nil {
+		return "", fmt.Errorf("${%s:%s}: %w", ConfigRef, path, err)
+	}
+	if target.Kind != yaml.ScalarNode {
+		return "", fmt.Errorf("${%s:%s}: cannot embed %s in a string", ConfigRef, path, target.ShortTag())
+	}
+	return target.Value, nil
+}
+
+// matchBrace 返回 s[open] 处的 { 对应的 } 位置，支持嵌套的 ${...}
+func matchBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// nodeAt 获取节点树中指定路径的节点，数组使用数字下标
+func nodeAt(root *yaml.Node, path string) *yaml.Node {
+	node := root
+	for _, seg := range strings.Split(path, ".") {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == seg {
+					next = node.Content[i+1]
+				}
+			}
+		case yaml.SequenceNode:
+			if idx, err := strconv.Atoi(seg); err == nil && idx >= 0 && idx < len(node.Content) {
+				next = node.Content[idx]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("EMPTY", "")
+	os.Setenv("PORT", "8080")
+	os.Setenv("INNER", "inner")
+	defer os.Clearenv()
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, `
+app:
+  name: demo
+  # 注释中的 ${MISSING:?not evaluated} 不会展开
+  port: ${PORT}
+  quoted: "${PORT}"
+  tagged: !!str ${PORT}
+  password: pa$$w$rd
+  dollar: cost $5
+  colon_default: ${EMPTY:-fallback}
+  dash_default: ${EMPTY-fallback}
+  unset_dash: ${MISSING-fallback}
+  legacy: ${MISSING:legacy}
+  nested: ${MISSING:-${ALSO_MISSING:-${INNER}}}
+  empty_required: ${EMPTY?must be set}
+  bool: ${MISSING:-true}
+  title: ${config:app.name}-${PORT}
+  alias: ${config:app.port}
+  copy: ${config:db}
+db:
+  host: localhost
+  url: mysql://${config:db.host}:${MISSING:-3306}
+`)
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	tests := map[string]interface{}{
+		"app.port":           8080,
+		"app.quoted":         "8080",
+		"app.tagged":         "8080",
+		"app.password":       "pa$w$rd",
+		"app.dollar":         "cost $5",
+		"app.colon_default":  "fallback",
+		"app.dash_default":   "",
+		"app.unset_dash":     "fallback",
+		"app.legacy":         "legacy",
+		"app.nested":         "inner",
+		"app.empty_required": "",
+		"app.bool":           true,
+		"app.title":          "demo-8080",
+		"app.alias":          8080,
+		"app.copy.host":      "localhost",
+		"db.url":             "mysql://localhost:3306",
+	}
+	for path, expected := range tests {
+		if got, _ := c.getValueByPath(path); got != expected {
+			t.Errorf("%s expected %#v, got %#v", path, expected, got)
+		}
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("EMPTY", "")
+	defer os.Clearenv()
+
+	tests := []struct {
+		content  string
+		contains string
+	}{
+		{"a: 1\nb: ${DB_PASS:?database password is required}\n", ":2: DB_PASS: database password is required"},
+		{"a: ${EMPTY:?}\n", ":1: EMPTY: is required"},
+		{"a: ${MISSING?}\n", ":1: MISSING: is required"},
+		{"a: ${UNCLOSED\n", ":1: unclosed"},
+		{"a: ${config:missing.key}\n", ":1: ${config:missing.key}"},
+		{"a: ${config:b}\nb: ${config:a}\n", "reference cycle"},
+		{"a: x${config:b}\nb: {c: 1}\n", "cannot embed !!map"},
+		{"a: ${VAR!x}\n", "invalid expression"},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, file, tt.content)
+		_, err := Load(file)
+		if err == nil || !strings.Contains(err.Error(), tt.contains) {
+			t.Errorf("Load(%q) expected error containing %q, got %v", tt.content, tt.contains, err)
+		}
+		if err != nil && !strings.HasPrefix(err.Error(), file) {
+			t.Errorf("Load(%q) expected error prefixed with file, got %v", tt.content, err)
+		}
+	}
+}
+
+func TestInterpolateAcrossLayers(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("GUTILS_APP__NAME", "from-env")
+	defer os.Clearenv()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	writeFile(t, file, "app:\n  name: base\n  mode: test\nlog:\n  path: /var/log/${config:app.name}\n")
+	writeFile(t, filepath.Join(dir, "config.test.yaml"), "app:\n  name: overlay\n")
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if c.GetString("log.path") != "/var/log/from-env" {
+		t.Errorf("log.path expected /var/log/from-env, got %q", c.GetString("log.path"))
+	}
+}
+
+func TestInterpolateSecretReference(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("REF_SECRET", "s3cret")
+	defer os.Clearenv()
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "db:\n  password: ${env:REF_SECRET}\n  dsn: root:${config:db.password}@tcp(db)\n")
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if c.GetString("db.dsn") != "root:s3cret@tcp(db)" {
+		t.Errorf("db.dsn unexpected %q", c.GetString("db.dsn"))
+	}
+	if !c.IsSecret("db.dsn") {
+		t.Errorf("db.dsn referencing a secret expected to be secret")
+	}
+}
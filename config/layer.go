@@ -0,0 +1,346 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IncludeKey 配置文件中引入其他配置片段的指令，路径相对于当前文件，支持通配符
+//
+//	include:
+//	  - common/redis.yaml
+//	  - conf.d/*.yaml
+const IncludeKey = "include"
+
+// LocalOverlay 本地覆盖文件的名称后缀，如 config.local.yaml
+const LocalOverlay = "local"
+
+// loader 按层加载配置文件：基础文件 -> config.<AppMode>.yaml -> config.local.yaml
+// 各层在 yaml 节点上深度合并：map 合并，标量与数组覆盖
+type loader struct {
+	// fsys 读取配置文件的文件系统，为空时读取磁盘
+	fsys fs.FS
+	// files 参与加载的文件（含尚不存在的覆盖文件），用于监听变更
+	files []string
+	// nodeFiles 记录每个节点来自哪个文件
+	nodeFiles map[*yaml.Node]string
+	// secretNodes 由密钥引用解析得到的节点
+	secretNodes map[*yaml.Node]bool
+	// deferred 含 ${config:...} 引用、待合并后解析的节点
+	deferred map[*yaml.Node]bool
+}
+
+func newLoader(fsys fs.FS) *loader {
+	return &loader{fsys: fsys, nodeFiles: map[*yaml.Node]string{}, secretNodes: map[*yaml.Node]bool{}, deferred: map[*yaml.Node]bool{}}
+}
+
+// layered 加载基础文件及其覆盖文件
+// 覆盖文件按环境变量与命令行参数覆盖后的 app.mode 选择，如 GUTILS_APP__MODE=test 时加载 config.test.yaml
+func (l *loader) layered(file string, o options) (*yaml.Node, error) {
+	root, err := l.parseFile(file, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := effectiveMode(scalarAt(root, "app", "mode"), o)
+	for _, overlay := range overlayFiles(file, mode) {
+		if _, err := l.stat(overlay); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				l.files = append(l.files, overlay)
+				continue
+			}
+			return nil, err
+		}
+
+		node, err := l.parseFile(overlay, nil)
+		if err != nil {
+			return nil, err
+		}
+		root = mergeNodes(root, node)
+	}
+	return root, nil
+}
+
+// effectiveMode 在基础文件的 app.mode 上应用环境变量与命令行参数，得到实际生效的运行模式
+func effectiveMode(base string, o options) string {
+	mode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: base}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "app"},
+		{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "mode"},
+			mode,
+		}},
+	}}
+	// 只用于读取 app.mode，其他配置项的错误在正式应用时返回
+	probe := newLoader(nil)
+	_ = probe.applyEnv(root, o)
+	_ = probe.applyFlags(root)
+	return scalarAt(root, "app", "mode")
+}
+
+// parseFile 解析单个文件，先合并 include 引入的片段，再由文件自身的值覆盖
+func (l *loader) parseFile(file string, stack []string) (*yaml.Node, error) {
+	abs, err := l.abs(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range stack {
+		if f == abs {
+			return nil, fmt.Errorf("%s: include cycle: %s", file, strings.Join(append(stack, abs), " -> "))
+		}
+	}
+	l.files = append(l.files, file)
+
+	content, err := l.readFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := parseContent(file, formatOf(file), content)
+	if err != nil {
+		return nil, err
+	}
+	return l.prepare(root, file, append(stack, abs))
+}
+
+// prepare 标记节点来源、展开环境变量与密钥引用，再合并 include 引入的片段（由文件自身的值覆盖）
+func (l *loader) prepare(root *yaml.Node, file string, stack []string) (*yaml.Node, error) {
+	l.markFile(root, file)
+	if err := l.interpolate(root, file); err != nil {
+		return nil, err
+	}
+
+	includes, err := l.takeIncludes(root, file)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	l.nodeFiles[merged] = file
+	for _, inc := range includes {
+		node, err := l.parseFile(inc, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeNodes(merged, node)
+	}
+	if len(includes) == 0 {
+		return root, nil
+	}
+	return mergeNodes(merged, root), nil
+}
+
+func (l *loader) readFile(name string) ([]byte, error) {
+	if l.fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(l.fsys, name)
+}
+
+func (l *loader) stat(name string) (fs.FileInfo, error) {
+	if l.fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(l.fsys, name)
+}
+
+func (l *loader) glob(pattern string) ([]string, error) {
+	if l.fsys == nil {
+		return filepath.Glob(pattern)
+	}
+	return fs.Glob(l.fsys, pattern)
+}
+
+func (l *loader) abs(name string) (string, error) {
+	if l.fsys == nil {
+		return filepath.Abs(name)
+	}
+	return path.Clean(name), nil
+}
+
+// resolve 将 include 路径解析为相对于 file 所在目录的路径
+func (l *loader) resolve(file, p string) string {
+	if l.fsys != nil {
+		return path.Join(path.Dir(file), p)
+	}
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(filepath.Dir(file), p)
+}
+
+// markFile 记录节点及其子节点的来源文件
+func (l *loader) markFile(node *yaml.Node, file string) {
+	l.nodeFiles[node] = file
+	for _, child := range node.Content {
+		l.markFile(child, file)
+	}
+}
+
+// origin 配置值的来源位置
+type origin struct {
+	file string
+	line int
+}
+
+// String 返回 file:line，无行号（如环境变量）时只返回来源
+func (o origin) String() string {
+	if o.line <= 0 {
+		return o.file
+	}
+	return o.file + ":" + strconv.Itoa(o.line)
+}
+
+// origins 生成 路径 -> 来源位置 的索引
+// 行号优先取 key 所在行；值被覆盖文件或环境变量替换时取值所在行
+func (l *loader) origins(root *yaml.Node) map[string]origin {
+	origins := map[string]origin{}
+	var walk func(path string, node *yaml.Node, key *yaml.Node)
+	walk = func(path string, node *yaml.Node, key *yaml.Node) {
+		if key != nil {
+			line := node.Line
+			if l.nodeFiles[key] == l.nodeFiles[node] {
+				line = key.Line
+			}
+			origins[path] = origin{file: l.nodeFiles[node], line: line}
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				walk(joinPath(path, key.Value), node.Content[i+1], key)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				walk(joinPath(path, strconv.Itoa(i)), item, item)
+			}
+		}
+	}
+	walk("", root, nil)
+	return origins
+}
+
+// walkNodes 遍历节点树，fn 接收每个非根节点的配置路径
+func walkNodes(node *yaml.Node, path string, fn func(path string, node *yaml.Node)) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			p := joinPath(path, node.Content[i].Value)
+			fn(p, node.Content[i+1])
+			walkNodes(node.Content[i+1], p, fn)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			p := joinPath(path, strconv.Itoa(i))
+			fn(p, item)
+			walkNodes(item, p, fn)
+		}
+	}
+}
+
+// takeIncludes 取出并移除顶层的 include 指令，返回引入文件列表
+func (l *loader) takeIncludes(root *yaml.Node, file string) ([]string, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != IncludeKey {
+			continue
+		}
+
+		val := root.Content[i+1]
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+
+		var patterns []string
+		switch val.Kind {
+		case yaml.ScalarNode:
+			patterns = []string{val.Value}
+		case yaml.SequenceNode:
+			if err := val.Decode(&patterns); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid %s: %w", file, val.Line, IncludeKey, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: %s must be a path or a list of paths", file, val.Line, IncludeKey)
+		}
+
+		var files []string
+		for _, p := range patterns {
+			p = l.resolve(file, p)
+			if !strings.ContainsAny(p, "*?[") {
+				files = append(files, p)
+				continue
+			}
+			matches, err := l.glob(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", file, val.Line, err)
+			}
+			files = append(files, matches...)
+		}
+		return files, nil
+	}
+	return nil, nil
+}
+
+// mergeNodes 将 src 深度合并到 dst：双方都是 map 时逐个 key 合并，否则 src 覆盖 dst
+func mergeNodes(dst, src *yaml.Node) *yaml.Node {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, val := src.Content[i], src.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				dst.Content[j+1] = mergeNodes(dst.Content[j+1], val)
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.Content = append(dst.Content, key, val)
+		}
+	}
+	return dst
+}
+
+// scalarAt 获取节点下指定路径的标量值
+func scalarAt(node *yaml.Node, keys ...string) string {
+	for _, k := range keys {
+		if node.Kind != yaml.MappingNode {
+			return ""
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == k {
+				next = node.Content[i+1]
+			}
+		}
+		if next == nil {
+			return ""
+		}
+		node = next
+	}
+	if node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// overlayFiles 返回基础配置文件对应的覆盖文件，如 config.yaml 对应
+// config.<mode>.yaml 与 config.local.yaml
+func overlayFiles(file, mode string) []string {
+	ext := filepath.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+
+	var files []string
+	if mode != "" && mode != LocalOverlay {
+		files = append(files, base+"."+mode+ext)
+	}
+	return append(files, base+"."+LocalOverlay+ext)
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLayered(t *testing.T) {
+	os.Clearenv()
+	dir := filepath.Join("testdata", "layered")
+	c, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		value  string
+		origin string
+	}{
+		{"app.name", "layered", "config.yaml"},
+		{"log.path", "./logs", "config.yaml"},
+		{"log.maxsize", "10", "config.test.yaml"},
+		{"database.test.host", "localhost", "config.local.yaml"},
+		{"redis.default.host", "redis.internal", filepath.Join("common", "redis.yaml")},
+		{"redis.default.db", "1", "config.yaml"},
+	}
+	for _, tt := range tests {
+		if got := c.GetString(tt.path); got != tt.value {
+			t.Errorf("%s expected '%s', got '%s'", tt.path, tt.value, got)
+		}
+		if got := c.Origin(tt.path); got != filepath.Join(dir, tt.origin) {
+			t.Errorf("%s origin expected '%s', got '%s'", tt.path, filepath.Join(dir, tt.origin), got)
+		}
+	}
+
+	if c.GetString("include") != "" {
+		t.Errorf("include directive should not be visible as config value")
+	}
+}
+
+func TestLoadLayeredMode(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("APP_MODE", "release")
+	defer os.Unsetenv("APP_MODE")
+
+	c, err := Load(filepath.Join("testdata", "layered", "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	// config.release.yaml 不存在，只应用 config.local.yaml
+	if c.GetInt("log.maxsize") != 1024 {
+		t.Errorf("log.maxsize expected 1024, got %d", c.GetInt("log.maxsize"))
+	}
+	if c.GetString("database.test.host") != "localhost" {
+		t.Errorf("database.test.host expected 'localhost', got '%s'", c.GetString("database.test.host"))
+	}
+}
+
+func TestLoadLayeredModeFromEnv(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("GUTILS_APP__MODE", "test")
+	defer os.Clearenv()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	for name, content := range map[string]string{
+		file:                                   "app:\n  mode: release\nx: 1\n",
+		filepath.Join(dir, "config.test.yaml"): "x: 2\n",
+	} {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	// 只通过环境变量指定的运行模式同样决定覆盖文件
+	if c.GetString("app.mode") != "test" || c.GetInt("x") != 2 {
+		t.Errorf("expected config.test.yaml applied, got mode %q x %d", c.GetString("app.mode"), c.GetInt("x"))
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), "include: b.yaml\na: 1\n")
+	writeFile(t, filepath.Join(dir, "b.yaml"), "include: a.yaml\nb: 1\n")
+
+	if _, err := Load(filepath.Join(dir, "a.yaml")); err == nil {
+		t.Errorf("Load expected include cycle error")
+	}
+}
+
+func TestPosition(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("GUTILS_LOG__PATH", "/var/log")
+	defer os.Clearenv()
+
+	dir := filepath.Join("testdata", "layered")
+	c, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	tests := map[string]string{
+		"app.name":           filepath.Join(dir, "config.yaml") + ":5",
+		"log":                filepath.Join(dir, "config.yaml") + ":8",
+		"log.maxsize":        filepath.Join(dir, "config.test.yaml") + ":2",
+		"log.path":           "env:GUTILS_LOG__PATH",
+		"redis.default.port": filepath.Join(dir, "common", "redis.yaml") + ":4",
+	}
+	for path, expected := range tests {
+		if got := c.Position(path); got != expected {
+			t.Errorf("%s position expected '%s', got '%s'", path, expected, got)
+		}
+	}
+}
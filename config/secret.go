@@ -0,0 +1,172 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue 脱敏后的配置值
+const RedactedValue = "******"
+
+// SecretProvider 根据引用获取密钥，如 ${file:/run/secrets/db_pass} 中的 /run/secrets/db_pass
+type SecretProvider func(ref string) (string, error)
+
+var (
+	secretMu        sync.RWMutex
+	secretProviders = map[string]SecretProvider{
+		"file":   fileSecret,
+		"env":    envSecret,
+		"base64": base64Secret,
+	}
+	secretPatterns []string
+)
+
+// RegisterSecretProvider 注册密钥来源，配置中 ${scheme:ref} 形式的值将通过 fn 解析
+// 内置 file（读取文件内容）、env（读取环境变量）与 base64（解码）
+//
+//	config.RegisterSecretProvider("vault", func(ref string) (string, error) {
+//		return vaultClient.Read(ref)
+//	})
+func RegisterSecretProvider(scheme string, fn SecretProvider) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretProviders[scheme] = fn
+}
+
+// MarkSecret 将匹配的配置路径标记为敏感值（支持 * 通配），脱敏输出时隐藏
+// 即使值直接写在配置文件中，如 database.*.master.password
+func MarkSecret(patterns ...string) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretPatterns = append(secretPatterns, patterns...)
+}
+
+func secretProvider(scheme string) (SecretProvider, bool) {
+	secretMu.RLock()
+	defer secretMu.RUnlock()
+	fn, ok := secretProviders[scheme]
+	return fn, ok
+}
+
+// splitSecretRef 拆分 scheme:ref，scheme 未注册时返回 false
+func splitSecretRef(expr string) (SecretProvider, string, bool) {
+	scheme, ref, ok := strings.Cut(expr, ":")
+	if !ok {
+		return nil, "", false
+	}
+	fn, ok := secretProvider(scheme)
+	return fn, ref, ok
+}
+
+// secretPaths 生成已解析密钥的路径集合
+func (l *loader) secretPaths(root *yaml.Node) map[string]bool {
+	paths := map[string]bool{}
+	walkNodes(root, "", func(path string, node *yaml.Node) {
+		if l.secretNodes[node] {
+			paths[path] = true
+		}
+	})
+	return paths
+}
+
+// IsSecret 判断配置值是否为敏感值：由密钥引用解析得到，或匹配 MarkSecret 注册的路径
+func (c *Config) IsSecret(path string) bool {
+	if c.state().secrets[path] {
+		return true
+	}
+
+	secretMu.RLock()
+	defer secretMu.RUnlock()
+	for _, pattern := range secretPatterns {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted 返回配置的副本，敏感值被替换为 RedactedValue
+func (c *Config) Redacted() map[string]interface{} {
+	m, _ := c.redact("", c.conf()).(map[string]interface{})
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m
+}
+
+func (c *Config) redact(path string, val interface{}) interface{} {
+	if path != "" && c.IsSecret(path) {
+		return RedactedValue
+	}
+
+	switch v := val.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = c.redact(joinPath(path, k), item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = c.redact(joinPath(path, strconv.Itoa(i)), item)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// IsSecret 判断默认配置中的值是否为敏感值
+func IsSecret(path string) bool {
+	return Default().IsSecret(path)
+}
+
+// Redacted 返回默认配置脱敏后的副本
+func Redacted() map[string]interface{} {
+	return Default().Redacted()
+}
+
+// matchPath 判断路径是否匹配模式，* 匹配一个路径段
+func matchPath(pattern, path string) bool {
+	patternParts, pathParts := strings.Split(pattern, "."), strings.Split(path, ".")
+	if len(patternParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if part != "*" && part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func fileSecret(ref string) (string, error) {
+	content, err := os.ReadFile(strings.TrimPrefix(ref, "//"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+func envSecret(ref string) (string, error) {
+	val, ok := os.LookupEnv(strings.TrimPrefix(ref, "//"))
+	if !ok {
+		return "", fmt.Errorf("environment variable %s not set", ref)
+	}
+	return val, nil
+}
+
+func base64Secret(ref string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
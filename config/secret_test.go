@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretReference(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "db_pass"), "p@ss$word\n")
+	_ = os.Setenv("REDIS_SECRET", "redis-pass")
+	defer os.Clearenv()
+
+	RegisterSecretProvider("test-vault", func(ref string) (string, error) {
+		if ref == "kv/api" {
+			return "vault-token", nil
+		}
+		return "", errors.New("not found")
+	})
+
+	file := filepath.Join(dir, "config.yaml")
+	writeFile(t, file, `database:
+  test:
+    password: ${file:`+filepath.Join(dir, "db_pass")+`}
+    numeric: ${base64:MTIzNDU2}
+redis:
+  default:
+    auth: ${env:REDIS_SECRET}
+api:
+  token: "Bearer ${test-vault:kv/api}"
+  name: ${API_NAME:api}
+`)
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	tests := map[string]string{
+		"database.test.password": "p@ss$word",
+		"database.test.numeric":  "123456",
+		"redis.default.auth":     "redis-pass",
+		"api.token":              "Bearer vault-token",
+		"api.name":               "api",
+	}
+	for path, expected := range tests {
+		if got := c.GetString(path); got != expected {
+			t.Errorf("%s expected '%s', got '%s'", path, expected, got)
+		}
+	}
+
+	// 密钥解析后保持字符串类型
+	if val, _ := c.getValueByPath("database.test.numeric"); val != "123456" {
+		t.Errorf("database.test.numeric expected string, got %T", val)
+	}
+
+	for _, path := range []string{"database.test.password", "redis.default.auth", "api.token"} {
+		if !c.IsSecret(path) {
+			t.Errorf("%s expected to be secret", path)
+		}
+	}
+	if c.IsSecret("api.name") {
+		t.Errorf("api.name expected not secret")
+	}
+
+	redacted := c.Redacted()
+	if redacted["api"].(map[string]interface{})["token"] != RedactedValue {
+		t.Errorf("api.token expected redacted, got %v", redacted["api"])
+	}
+	if redacted["api"].(map[string]interface{})["name"] != "api" {
+		t.Errorf("api.name expected unchanged, got %v", redacted["api"])
+	}
+	if c.GetString("api.token") != "Bearer vault-token" {
+		t.Errorf("Redacted must not modify config")
+	}
+}
+
+func TestMarkSecret(t *testing.T) {
+	os.Clearenv()
+	MarkSecret("secret_test.*.password")
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "secret_test:\n  a:\n    user: root\n    password: plain\n")
+	c, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.IsSecret("secret_test.a.password") || c.IsSecret("secret_test.a.user") {
+		t.Errorf("MarkSecret pattern not applied")
+	}
+	a := c.Redacted()["secret_test"].(map[string]interface{})["a"].(map[string]interface{})
+	if a["password"] != RedactedValue || a["user"] != "root" {
+		t.Errorf("unexpected redacted value: %v", a)
+	}
+}
+
+func TestSecretError(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "db:\n  password: ${file:/nonexistent/secret}\n")
+
+	if _, err := Load(file); err == nil {
+		t.Errorf("Load expected error for missing secret file")
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "app:\n  name: v1\n  mode: debug\n")
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	snap := c.Snapshot()
+
+	writeFile(t, file, "app:\n  name: v2\n  mode: debug\n")
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+
+	if snap.GetString("app.name") != "v1" {
+		t.Errorf("snapshot expected v1, got %s", snap.GetString("app.name"))
+	}
+	if c.GetString("app.name") != "v2" {
+		t.Errorf("config expected v2 after reload, got %s", c.GetString("app.name"))
+	}
+	if err := snap.Reload(); err == nil {
+		t.Errorf("snapshot Reload expected error")
+	}
+}
+
+func TestNameMode(t *testing.T) {
+	os.Clearenv()
+	old := Default()
+	defer SetDefaultConfig(old)
+
+	SetDefaultConfig(&Config{})
+	if Name() != "app" || Mode() != "release" {
+		t.Errorf("Name/Mode expected defaults, got %s/%s", Name(), Mode())
+	}
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "app:\n  name: svc\n  mode: debug\n")
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	SetDefaultConfig(c)
+	if Name() != "svc" || Mode() != "debug" {
+		t.Errorf("Name/Mode expected svc/debug, got %s/%s", Name(), Mode())
+	}
+
+	// 默认配置 Reload 后 Mode 随之更新
+	writeFile(t, file, "app:\n  name: svc\n  mode: test\n")
+	if err := Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+	if Mode() != "test" {
+		t.Errorf("Mode expected test after reload, got %s", Mode())
+	}
+}
+
+func TestOnChangeBeforeSetDefault(t *testing.T) {
+	os.Clearenv()
+	old := Default()
+	defer SetDefaultConfig(old)
+
+	// SetDefault 之前注册的回调在首次设置配置时收到通知
+	SetDefaultConfig(&Config{})
+	var got []interface{}
+	OnChange("early.value", func(oldVal, newVal interface{}) {
+		got = append(got, oldVal, newVal)
+	})
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "early:\n  value: 1\n")
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	SetDefaultConfig(c)
+	if len(got) != 2 || got[0] != nil || got[1] != 1 {
+		t.Errorf("expected change from nil to 1, got %v", got)
+	}
+}
+
+// TestConcurrentReload 需要配合 go test -race 运行
+func TestConcurrentReload(t *testing.T) {
+	os.Clearenv()
+	old := Default()
+	defer SetDefaultConfig(old)
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := func(i int) string {
+		return fmt.Sprintf("app:\n  name: v%d\n  mode: debug\nredis:\n  default:\n    host: h%d\n    port: %d\n", i, i, 6000+i)
+	}
+	writeFile(t, file, content(0))
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	SetDefaultConfig(c)
+	OnChange("redis", func(_, _ interface{}) {})
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// 同一个快照中的值来自同一次加载
+				snap := Snapshot()
+				name, host, port := snap.GetString("app.name"), snap.GetString("redis.default.host"), snap.GetInt("redis.default.port")
+				var i int
+				if _, err := fmt.Sscanf(name, "v%d", &i); err != nil || host != fmt.Sprintf("h%d", i) || port != 6000+i {
+					t.Errorf("inconsistent snapshot: %s %s %d", name, host, port)
+					return
+				}
+
+				_ = Name()
+				_ = Mode()
+				_ = GetStringMap("redis")
+				_ = AllKeys()
+				_ = Redacted()
+			}
+		}()
+	}
+
+	for i := 1; i <= 50; i++ {
+		writeFile(t, file, content(i))
+		if err := c.Reload(); err != nil {
+			t.Errorf("Reload error: %v", err)
+		}
+		if i%10 == 0 {
+			SetDefaultConfig(c)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSourceTimeout 从远程来源读取配置的超时时间
+const DefaultSourceTimeout = 3 * time.Second
+
+// Source 远程配置来源，如 Redis、etcd、Consul
+// 远程配置覆盖配置文件，优先级低于环境变量与命令行参数
+type Source interface {
+	// Name 来源名称，用于来源位置与错误信息，如 redis:app:config
+	Name() string
+	// Load 读取全部配置项，key 为配置路径（如 log.level），value 按原有值的类型转换
+	Load(ctx context.Context) (map[string]string, error)
+	// Watch 阻塞监听配置变更，变更时调用 notify，ctx 取消时返回
+	Watch(ctx context.Context, notify func()) error
+}
+
+type sourceOption struct {
+	src Source
+	// cache 最近一次成功读取的远程配置的缓存文件，为空时不缓存
+	cache string
+}
+
+// WithSource 使用远程来源覆盖配置，cache 为缓存文件路径（为空时不缓存）
+// 远程来源不可用时使用缓存中最近一次成功读取的配置，缓存也不存在时只使用配置文件
+//
+//	src := redis.NewConfigSource(client, "app:config", "app:config:changed")
+//	c, err := config.Load("conf/config.yaml", config.WithSource(src, "runtime/app-config.json"))
+func WithSource(src Source, cache string) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, sourceOption{src: src, cache: cache})
+	}
+}
+
+// applySources 使用远程来源的配置项覆盖配置
+// 远程来源的问题不影响配置文件：无法应用的配置项记录日志后跳过
+func (l *loader) applySources(root *yaml.Node, o options) {
+	for _, so := range o.sources {
+		values := so.load()
+
+		paths := make([]string, 0, len(values))
+		for p := range values {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			if err := l.setValue(root, so.src.Name()+":"+p, strings.Split(p, "."), values[p]); err != nil {
+				log.Printf("[config] source %s: skip %s: %s", so.src.Name(), p, err)
+			}
+		}
+	}
+}
+
+// load 读取远程配置并更新缓存，读取失败时回退到缓存，缓存也不可用时返回 nil
+func (so sourceOption) load() map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultSourceTimeout)
+	defer cancel()
+
+	values, err := so.src.Load(ctx)
+	if err == nil {
+		if so.cache != "" {
+			if err := so.writeCache(values); err != nil {
+				log.Printf("[config] source %s: write cache failed: %s", so.src.Name(), err)
+			}
+		}
+		return values
+	}
+
+	if so.cache == "" {
+		log.Printf("[config] source %s unavailable: %s", so.src.Name(), err)
+		return nil
+	}
+	cached, cacheErr := so.readCache()
+	switch {
+	case os.IsNotExist(cacheErr):
+		log.Printf("[config] source %s unavailable: %s", so.src.Name(), err)
+		return nil
+	case cacheErr != nil:
+		log.Printf("[config] source %s unavailable: %s, read cache %s: %s", so.src.Name(), err, so.cache, cacheErr)
+		return nil
+	}
+	log.Printf("[config] source %s unavailable, using cache %s: %s", so.src.Name(), so.cache, err)
+	return cached
+}
+
+func (so sourceOption) readCache() (map[string]string, error) {
+	content, err := os.ReadFile(so.cache)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]string
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// writeCache 内容变化时写入缓存，先写临时文件再重命名，避免读到写了一半的文件
+// 远程配置可能包含密钥，缓存文件仅所有者可读写
+func (so sourceOption) writeCache(values map[string]string) error {
+	if cached, err := so.readCache(); err == nil && reflect.DeepEqual(cached, values) {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(so.cache), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(so.cache), filepath.Base(so.cache)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), so.cache)
+}
+
+// watchSources 监听远程来源的推送并重新加载，监听中断时间隔 interval 后重试
+func (c *Config) watchSources(ctx context.Context, interval time.Duration) {
+	for _, so := range c.opts.sources {
+		go func(src Source) {
+			for {
+				err := src.Watch(ctx, func() {
+					if err := c.Reload(); err != nil {
+						c.reportError(err)
+					}
+				})
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					c.reportError(fmt.Errorf("config: source %s: watch: %w", src.Name(), err))
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(interval):
+				}
+			}
+		}(so.src)
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memSource 内存中的远程来源
+type memSource struct {
+	mu      sync.Mutex
+	values  map[string]string
+	down    bool
+	changed chan struct{}
+}
+
+func newMemSource(values map[string]string) *memSource {
+	return &memSource{values: values, changed: make(chan struct{}, 1)}
+}
+
+func (s *memSource) Name() string { return "mem" }
+
+func (s *memSource) Load(context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return nil, errors.New("connection refused")
+	}
+	values := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		values[k] = v
+	}
+	return values, nil
+}
+
+func (s *memSource) Watch(ctx context.Context, notify func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.changed:
+			notify()
+		}
+	}
+}
+
+func (s *memSource) set(key, value string, down bool) {
+	s.mu.Lock()
+	s.values[key] = value
+	s.down = down
+	s.mu.Unlock()
+}
+
+func TestSource(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("GUTILS_LOG__ENCODE_TYPE", "json")
+	defer os.Clearenv()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	writeFile(t, file, "log:\n  level: info\n  maxsize: 100\n  encode_type: mis\n")
+	cache := filepath.Join(dir, "runtime", "remote.json")
+
+	src := newMemSource(map[string]string{"log.level": "debug", "log.maxsize": "200", "log.encode_type": "console", "feature.x": "true"})
+	c, err := Load(file, WithSource(src, cache))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	tests := map[string]interface{}{
+		"log.level":       "debug",
+		"log.maxsize":     200,
+		"log.encode_type": "json", // 环境变量优先于远程配置
+		"feature.x":       true,
+	}
+	for path, expected := range tests {
+		if got, _ := c.getValueByPath(path); got != expected {
+			t.Errorf("%s expected %#v, got %#v", path, expected, got)
+		}
+	}
+	if c.Position("log.level") != "mem:log.level" {
+		t.Errorf("log.level position expected mem:log.level, got %s", c.Position("log.level"))
+	}
+	if _, err := os.Stat(cache); err != nil {
+		t.Errorf("cache expected to be written: %v", err)
+	}
+
+	// 远程不可用时使用缓存
+	src.set("log.level", "warn", true)
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+	if c.GetString("log.level") != "debug" {
+		t.Errorf("log.level expected cached debug, got %s", c.GetString("log.level"))
+	}
+
+	// 缓存也不存在时只使用配置文件
+	os.Remove(cache)
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload without cache error: %v", err)
+	}
+	if c.GetString("log.level") != "info" {
+		t.Errorf("log.level expected file value info, got %s", c.GetString("log.level"))
+	}
+}
+
+func TestSourceInvalidValue(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "log:\n  maxsize: 100\n")
+
+	// 无法应用的配置项被跳过，其他配置项照常生效
+	src := newMemSource(map[string]string{"log.maxsize": "big", "log.level": "debug"})
+	c, err := Load(file, WithSource(src, ""))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if c.GetInt("log.maxsize") != 100 || c.GetString("log.level") != "debug" {
+		t.Errorf("expected file maxsize and remote level, got %d %s", c.GetInt("log.maxsize"), c.GetString("log.level"))
+	}
+
+	// 缓存文件损坏时只使用配置文件
+	cache := filepath.Join(t.TempDir(), "remote.json")
+	writeFile(t, cache, "{broken")
+	src.set("log.level", "warn", true)
+	c, err = Load(file, WithSource(src, cache))
+	if err != nil {
+		t.Fatalf("Load with broken cache error: %v", err)
+	}
+	if c.GetString("log.level") != "" || c.GetInt("log.maxsize") != 100 {
+		t.Errorf("expected file values only, got %q %d", c.GetString("log.level"), c.GetInt("log.maxsize"))
+	}
+}
+
+func TestSourceWatch(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "log:\n  level: info\n")
+
+	src := newMemSource(map[string]string{"log.level": "debug"})
+	c, err := Load(file, WithSource(src, ""))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	changed := make(chan interface{}, 1)
+	c.OnChange("log.level", func(_, v interface{}) {
+		select {
+		case changed <- v:
+		default:
+		}
+	})
+	stop := c.Watch(time.Hour)
+	defer stop()
+
+	src.set("log.level", "error", false)
+	src.changed <- struct{}{}
+
+	select {
+	case v := <-changed:
+		if v != "error" {
+			t.Errorf("log.level expected error, got %v", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnChange not called after source push")
+	}
+}
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Rule 配置校验规则，返回发现的全部问题
+// 规则中的路径支持 * 通配一层 map key 或数组下标，如 database.*.master.host
+type Rule func(c *Config) []Violation
+
+// Violation 一条校验失败信息
+type Violation struct {
+	Path    string
+	Message string
+	// Position 值的来源位置 file:line，由 Validate 根据 yaml 节点位置填充
+	Position string
+}
+
+func (v Violation) Error() string {
+	if v.Position == "" {
+		return fmt.Sprintf("%s: %s", v.Path, v.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", v.Position, v.Path, v.Message)
+}
+
+// ValidationError 汇总一次校验发现的全部问题
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Violations)+1)
+	lines = append(lines, fmt.Sprintf("config: %d validation error(s):", len(e.Violations)))
+	for _, v := range e.Violations {
+		lines = append(lines, "  "+v.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   []Rule
+)
+
+// AddRule 注册配置校验规则，通常在各包的 init 中调用
+func AddRule(r ...Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules = append(rules, r...)
+}
+
+// Validate 使用已注册的规则校验默认配置
+func Validate() error {
+	return Default().Validate()
+}
+
+// Validate 使用已注册的规则校验配置，一次返回全部问题
+func (c *Config) Validate() error {
+	rulesMu.RLock()
+	rs := append([]Rule(nil), rules...)
+	rulesMu.RUnlock()
+
+	return c.validate(rs)
+}
+
+func (c *Config) validate(rs []Rule) error {
+	var violations []Violation
+	for _, r := range rs {
+		for _, v := range r(c) {
+			if v.Position == "" {
+				v.Position = c.position(v.Path)
+			}
+			violations = append(violations, v)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: violations}
+}
+
+// position 返回路径的来源位置，路径不存在时使用最近的上级路径
+func (c *Config) position(path string) string {
+	origins := c.state().origins
+	for path != "" {
+		if o, ok := origins[path]; ok {
+			return o.String()
+		}
+		idx := strings.LastIndex(path, ".")
+		if idx < 0 {
+			break
+		}
+		path = path[:idx]
+	}
+	return c.file
+}
+
+// Required 要求配置存在且不为空
+// 通配路径只检查已存在的上级节点，如 redis.*.host 要求每个 redis 配置都设置 host
+func Required(paths ...string) Rule {
+	return func(c *Config) (vs []Violation) {
+		for _, pattern := range paths {
+			var candidates []string
+			parent, key := splitLast(pattern)
+			switch {
+			case !strings.Contains(pattern, "*"):
+				candidates = []string{pattern}
+			case key == "*":
+				candidates = c.expand(pattern)
+			default:
+				for _, p := range c.expand(parent) {
+					candidates = append(candidates, joinPath(p, key))
+				}
+			}
+
+			for _, path := range candidates {
+				val, ok := c.getValueByPath(path)
+				if !ok || val == nil || val == "" {
+					vs = append(vs, Violation{Path: path, Message: "is required"})
+				}
+			}
+		}
+		return
+	}
+}
+
+// OneOf 要求配置值为给定值之一，配置不存在时跳过
+func OneOf(path string, values ...interface{}) Rule {
+	allowed := make([]string, len(values))
+	for i, v := range values {
+		allowed[i] = fmt.Sprintf("%v", v)
+	}
+
+	return func(c *Config) (vs []Violation) {
+		for _, p := range c.expand(path) {
+			val, _ := c.getValueByPath(p)
+			if val == nil {
+				continue
+			}
+			s := fmt.Sprintf("%v", val)
+			found := false
+			for _, a := range allowed {
+				if s == a {
+					found = true
+					break
+				}
+			}
+			if !found {
+				vs = append(vs, Violation{Path: p, Message: fmt.Sprintf("%q must be one of [%s]", s, strings.Join(allowed, ", "))})
+			}
+		}
+		return
+	}
+}
+
+// Range 要求配置值为 [min, max] 范围内的数字，配置不存在时跳过
+func Range(path string, min, max float64) Rule {
+	return func(c *Config) (vs []Violation) {
+		for _, p := range c.expand(path) {
+			val, _ := c.getValueByPath(p)
+			if val == nil {
+				continue
+			}
+			f, err := toFloat64E(val)
+			if err != nil {
+				vs = append(vs, Violation{Path: p, Message: err.Error()})
+				continue
+			}
+			if f < min || f > max {
+				vs = append(vs, Violation{Path: p, Message: fmt.Sprintf("%v out of range [%v, %v]", val, min, max)})
+			}
+		}
+		return
+	}
+}
+
+// Match 要求配置值匹配正则表达式，配置不存在时跳过
+func Match(path string, pattern string) Rule {
+	re := regexp.MustCompile(pattern)
+	return func(c *Config) (vs []Violation) {
+		for _, p := range c.expand(path) {
+			val, _ := c.getValueByPath(p)
+			if val == nil {
+				continue
+			}
+			if s := fmt.Sprintf("%v", val); !re.MatchString(s) {
+				vs = append(vs, Violation{Path: p, Message: fmt.Sprintf("%q does not match %s", s, pattern)})
+			}
+		}
+		return
+	}
+}
+
+// Requires 配置了 path 时 dependency 也必须存在，两者中的 * 按位置对应
+//
+//	config.Requires("database.*.slaves", "database.*.master")
+func Requires(path, dependency string) Rule {
+	return func(c *Config) (vs []Violation) {
+		for _, p := range c.expand(path) {
+			dep := bindWildcards(path, p, dependency)
+			if val, ok := c.getValueByPath(dep); !ok || val == nil {
+				vs = append(vs, Violation{Path: p, Message: fmt.Sprintf("requires %s", dep)})
+			}
+		}
+		return
+	}
+}
+
+// expand 展开路径中的 * 通配，只返回实际存在的路径
+func (c *Config) expand(pattern string) []string {
+	if !strings.Contains(pattern, "*") {
+		if _, ok := c.getValueByPath(pattern); ok {
+			return []string{pattern}
+		}
+		return nil
+	}
+
+	var paths []string
+	var walk func(prefix string, val interface{}, parts []string)
+	walk = func(prefix string, val interface{}, parts []string) {
+		if len(parts) == 0 {
+			paths = append(paths, prefix)
+			return
+		}
+		part, rest := parts[0], parts[1:]
+		switch v := val.(type) {
+		case map[string]interface{}:
+			if part != "*" {
+				if next, ok := v[part]; ok {
+					walk(joinPath(prefix, part), next, rest)
+				}
+				return
+			}
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(joinPath(prefix, k), v[k], rest)
+			}
+		case []interface{}:
+			for i, item := range v {
+				if part == "*" || part == strconv.Itoa(i) {
+					walk(joinPath(prefix, strconv.Itoa(i)), item, rest)
+				}
+			}
+		}
+	}
+	walk("", c.conf(), strings.Split(pattern, "."))
+	return paths
+}
+
+// bindWildcards 将 pattern 中 * 匹配到的路径段依次代入 target
+func bindWildcards(pattern, path, target string) string {
+	patternParts, pathParts := strings.Split(pattern, "."), strings.Split(path, ".")
+	var bound []string
+	for i, part := range patternParts {
+		if part == "*" && i < len(pathParts) {
+			bound = append(bound, pathParts[i])
+		}
+	}
+
+	targetParts := strings.Split(target, ".")
+	for i, part := range targetParts {
+		if part == "*" && len(bound) > 0 {
+			targetParts[i], bound = bound[0], bound[1:]
+		}
+	}
+	return strings.Join(targetParts, ".")
+}
+
+func splitLast(path string) (parent, key string) {
+	idx := strings.LastIndex(path, ".")
+	if idx < 0 {
+		return "", path
+	}
+	return path[:idx], path[idx+1:]
+}
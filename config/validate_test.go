@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, `app:
+  name: validate
+database:
+  ok:
+    master:
+      drive: mysql
+      host: 127.0.0.1
+  typo:
+    master:
+      drive: mysq
+      port: 70000
+  orphan:
+    slaves:
+      - host: 127.0.0.1
+`)
+	c, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.validate([]Rule{
+		Required("app.name", "app.mode"),
+		OneOf("database.*.master.drive", "mysql", "postgres"),
+		Required("database.*.master.host"),
+		Range("database.*.master.port", 1, 65535),
+		Requires("database.*.slaves", "database.*.master"),
+		Match("app.name", "^[a-z-]+$"),
+	})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+
+	expected := []string{
+		file + ":1: app.mode: is required",
+		file + ":10: database.typo.master.drive: \"mysq\" must be one of [mysql, postgres]",
+		file + ":9: database.typo.master.host: is required",
+		file + ":11: database.typo.master.port: 70000 out of range [1, 65535]",
+		file + ":13: database.orphan.slaves: requires database.orphan.master",
+	}
+	if len(verr.Violations) != len(expected) {
+		t.Fatalf("expected %d violations, got %d:\n%v", len(expected), len(verr.Violations), err)
+	}
+	for i, v := range verr.Violations {
+		if v.Error() != expected[i] {
+			t.Errorf("violation %d expected %q, got %q", i, expected[i], v.Error())
+		}
+	}
+}
+
+func TestValidateOnInit(t *testing.T) {
+	os.Clearenv()
+	AddRule(func(c *Config) []Violation {
+		// 只校验本测试的配置，避免影响其他测试
+		if _, ok := c.getValueByPath("validate_test"); !ok {
+			return nil
+		}
+		return Required("validate_test.required")(c)
+	})
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "validate_test:\n  other: 1\n")
+	err := Init(file)
+	if err == nil || !strings.Contains(err.Error(), "validate_test.required: is required") {
+		t.Fatalf("Init expected validation error, got %v", err)
+	}
+
+	// 校验失败的配置不会被重新加载
+	writeFile(t, file, "validate_test:\n  required: true\n")
+	if err := Init(file); err != nil {
+		t.Fatalf("Init error: %v", err)
+	}
+	writeFile(t, file, "validate_test:\n  required: \"\"\n")
+	if err := Reload(); err == nil {
+		t.Errorf("Reload expected validation error")
+	}
+	if !GetBool("validate_test.required") {
+		t.Errorf("validate_test.required expected old value after failed reload")
+	}
+}
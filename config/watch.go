@@ -0,0 +1,242 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// DefaultWatchInterval 配置文件变更检查周期
+const DefaultWatchInterval = 5 * time.Second
+
+type subscriber struct {
+	path string
+	fn   func(old, new interface{})
+}
+
+var (
+	// defaultSubs 通过包级函数注册的回调，作用于默认配置，替换默认实例后仍然有效
+	defaultSubs = &Config{}
+
+	// envFromFile 记录由 .env 文件设置的环境变量，重新加载时只覆盖这部分
+	envMu       sync.Mutex
+	envFromFile = map[string]string{}
+)
+
+// OnChange 注册默认配置的变更回调，见 Config.OnChange
+func OnChange(path string, fn func(old, new interface{})) {
+	defaultSubs.OnChange(path, fn)
+}
+
+// OnReloadError 注册默认配置重新加载失败回调，见 Config.OnReloadError
+func OnReloadError(fn func(err error)) {
+	defaultSubs.OnReloadError(fn)
+}
+
+// Reload 重新加载默认配置
+func Reload() error {
+	return Default().Reload()
+}
+
+// Watch 监听默认配置文件的变更，见 Config.Watch
+func Watch(interval time.Duration) (stop func()) {
+	return Default().Watch(interval)
+}
+
+// OnChange 注册配置变更回调，path 为空时监听整个配置
+// 重新加载后仅当 path 对应的值发生变化时才回调，old/new 为变更前后的值（不存在时为 nil）
+func (c *Config) OnChange(path string, fn func(old, new interface{})) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	c.subscribers = append(c.subscribers, subscriber{path: path, fn: fn})
+}
+
+// OnReloadError 注册重新加载失败回调，未注册时使用标准库 log 输出
+func (c *Config) OnReloadError(fn func(err error)) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	c.errHandlers = append(c.errHandlers, fn)
+}
+
+// Reload 重新读取 .env 与配置文件并原子替换当前配置
+// 解析或校验失败时保留原配置并返回错误
+func (c *Config) Reload() error {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	if c.file == "" {
+		return errors.New("config: no config file to reload")
+	}
+
+	loadEnvFiles(envFiles(c.file)...)
+	st, err := loadState(c.file, c.opts)
+	if err != nil {
+		return err
+	}
+	if err := newConfig(c.file, c.opts, st).Validate(); err != nil {
+		return err
+	}
+
+	old := c.state()
+	c.st.Store(st)
+
+	c.notify(old.data, st.data)
+	if Default() == c {
+		defaultSubs.notify(old.data, st.data)
+	}
+	return nil
+}
+
+// Watch 启动配置文件监听，任一参与加载的文件（含覆盖文件、include 片段及 .env）
+// 修改、远程来源推送变更或进程收到 SIGHUP 时重新加载
+// interval <= 0 时使用 DefaultWatchInterval，返回的函数用于停止监听
+func (c *Config) Watch(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.watchSources(ctx, interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		defer signal.Stop(sighup)
+
+		files := func() []string {
+			return append(c.Files(), envFiles(c.file)...)
+		}
+		last := fileStamps(files())
+		for {
+			select {
+			case <-done:
+				return
+			case <-sighup:
+			case <-ticker.C:
+				current := fileStamps(files())
+				if reflect.DeepEqual(last, current) {
+					continue
+				}
+			}
+
+			last = fileStamps(files())
+			if err := c.Reload(); err != nil {
+				c.reportError(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			close(done)
+		})
+	}
+}
+
+// notify 通知值发生变化的订阅者
+func (c *Config) notify(oldConf, newConf map[string]interface{}) {
+	c.subMu.Lock()
+	subs := append([]subscriber(nil), c.subscribers...)
+	c.subMu.Unlock()
+
+	for _, s := range subs {
+		var oldVal, newVal interface{} = oldConf, newConf
+		if s.path != "" {
+			oldVal, _ = lookup(oldConf, s.path)
+			newVal, _ = lookup(newConf, s.path)
+		}
+		if !reflect.DeepEqual(oldVal, newVal) {
+			s.fn(oldVal, newVal)
+		}
+	}
+}
+
+func (c *Config) reportError(err error) {
+	c.subMu.Lock()
+	handlers := append(([]func(error))(nil), c.errHandlers...)
+	c.subMu.Unlock()
+	if Default() == c {
+		defaultSubs.subMu.Lock()
+		handlers = append(handlers, defaultSubs.errHandlers...)
+		defaultSubs.subMu.Unlock()
+	}
+
+	if len(handlers) == 0 {
+		log.Println("[config] reload failed: " + err.Error())
+		return
+	}
+	for _, fn := range handlers {
+		fn(err)
+	}
+}
+
+// envFiles 返回配置文件对应的 .env 文件：配置文件同目录下的 .env，然后是当前目录
+func envFiles(file string) []string {
+	return []string{filepath.Join(filepath.Dir(file), ".env"), ".env"}
+}
+
+// loadEnvFiles 加载 .env 文件，进程原有的环境变量优先
+// 先加载的文件优先；此前由 .env 设置的变量会被更新，已从文件中删除的变量会被清除
+func loadEnvFiles(files ...string) {
+	envMu.Lock()
+	defer envMu.Unlock()
+
+	loaded := map[string]string{}
+	for _, f := range files {
+		vars, err := godotenv.Read(f)
+		if err != nil {
+			continue
+		}
+		for k, v := range vars {
+			if _, ok := loaded[k]; ok {
+				continue
+			}
+			if _, fromFile := envFromFile[k]; !fromFile {
+				if _, exists := os.LookupEnv(k); exists {
+					continue
+				}
+			}
+			loaded[k] = v
+		}
+	}
+
+	for k := range envFromFile {
+		if _, ok := loaded[k]; !ok {
+			_ = os.Unsetenv(k)
+		}
+	}
+	for k, v := range loaded {
+		_ = os.Setenv(k, v)
+	}
+	envFromFile = loaded
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func fileStamps(files []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(files))
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			stamps[f] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+		}
+	}
+	return stamps
+}
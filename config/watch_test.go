@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "app:\n  name: reload\n  mode: release\nlog:\n  maxsize: 10\n")
+	SetDefault(file)
+
+	var oldVal, newVal interface{}
+	calls := 0
+	OnChange("log.maxsize", func(o, n interface{}) {
+		calls++
+		oldVal, newVal = o, n
+	})
+
+	writeFile(t, file, "app:\n  name: reload\n  mode: debug\nlog:\n  maxsize: 20\n")
+	if err := Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+	if GetString("app.mode") != "debug" {
+		t.Errorf("app.mode expected 'debug' after reload, got '%s'", GetString("app.mode"))
+	}
+	if calls != 1 || oldVal != 10 || newVal != 20 {
+		t.Errorf("OnChange expected 1 call 10 -> 20, got %d calls %v -> %v", calls, oldVal, newVal)
+	}
+
+	// 值未变化时不回调
+	if err := Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("OnChange expected no call for unchanged value, got %d calls", calls)
+	}
+
+	// 解析失败时保留原配置
+	writeFile(t, file, "app: [unclosed\n")
+	if err := Reload(); err == nil {
+		t.Errorf("Reload expected parse error")
+	}
+	if GetInt("log.maxsize") != 20 {
+		t.Errorf("log.maxsize expected old value 20 after failed reload, got %d", GetInt("log.maxsize"))
+	}
+}
+
+func TestReloadEnvFile(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	writeFile(t, file, "app:\n  name: ${APP_NAME:none}\n")
+	writeFile(t, filepath.Join(dir, ".env"), "APP_NAME=first\n")
+	SetDefault(file)
+
+	if GetString("app.name") != "first" {
+		t.Fatalf("app.name expected 'first', got '%s'", GetString("app.name"))
+	}
+
+	writeFile(t, filepath.Join(dir, ".env"), "APP_NAME=second\n")
+	if err := Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+	if GetString("app.name") != "second" {
+		t.Errorf("app.name expected 'second' after .env change, got '%s'", GetString("app.name"))
+	}
+}
+
+func TestWatch(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, file, "watch:\n  value: 1\n")
+	SetDefault(file)
+
+	changed := make(chan interface{}, 1)
+	OnChange("watch.value", func(_, n interface{}) {
+		select {
+		case changed <- n:
+		default:
+		}
+	})
+
+	stop := Watch(10 * time.Millisecond)
+	defer stop()
+
+	// 保证修改时间与初次加载不同
+	time.Sleep(20 * time.Millisecond)
+	writeFile(t, file, "watch:\n  value: 22\n")
+
+	select {
+	case n := <-changed:
+		if n != 22 {
+			t.Errorf("watch.value expected 22, got %v", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("watcher did not reload changed file")
+	}
+}
@@ -1,231 +1,149 @@
 package config
 
 import (
-	"fmt"
-	"github.com/joho/godotenv"
-	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync/atomic"
 )
 
 var (
 	AppPath string
+	// Deprecated: 并发读取不安全且不随 Reload 更新，使用 Name()
 	AppName string
+	// Deprecated: 并发读取不安全且不随 Reload 更新，使用 Mode()
 	AppMode string
 
-	defaultConf map[string]interface{}
+	defaultConfig atomic.Pointer[Config]
 )
 
+func init() {
+	defaultConfig.Store(&Config{})
+}
+
 // SetDefault 初始化配置
-func SetDefault(file string) {
-	var err error
-	if AppPath, err = filepath.Abs(filepath.Dir(os.Args[0])); err != nil {
+func SetDefault(file string, opts ...Option) {
+	if err := Init(file, opts...); err != nil {
 		panic(err)
 	}
-
-	// 加载 .env 文件（忽略不存在错误）
-	// 首先尝试加载配置文件同目录下的 .env，然后尝试当前目录
-	configDir := filepath.Dir(file)
-	_ = godotenv.Load(filepath.Join(configDir, ".env"))
-	_ = godotenv.Load()
-
-	// 加载配置文件
-	defaultConf = NewConfig(file)
-	AppName = GetStringWithDefault("app.name", "app")
-	AppMode = GetStringWithDefault("app.mode", "release")
 }
 
-// NewConfig 加载 YAML 配置文件并替换环境变量
-func NewConfig(configFile string) map[string]interface{} {
-	content, err := os.ReadFile(configFile)
-	if err != nil {
-		panic(err)
+// Init 初始化默认配置，与 SetDefault 相同但返回错误而不是 panic
+// 加载后使用已注册的规则校验配置，返回的 *ValidationError 包含全部问题
+func Init(file string, opts ...Option) error {
+	var err error
+	if AppPath, err = filepath.Abs(filepath.Dir(os.Args[0])); err != nil {
+		return err
 	}
 
-	// 替换环境变量
-	expanded := expandEnv(string(content))
-
-	// 解析 YAML
-	var conf map[string]interface{}
-	if err := yaml.Unmarshal([]byte(expanded), &conf); err != nil {
-		panic(err)
+	c, err := Load(file, opts...)
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return err
 	}
+	SetDefaultConfig(c)
+	return nil
+}
 
-	return conf
+// Default 获取默认配置实例，包级函数均作用于该实例
+func Default() *Config {
+	return defaultConfig.Load()
 }
 
-// expandEnv 替换 ${ENV_VAR} 和 ${ENV_VAR:default} 格式的环境变量
-func expandEnv(content string) string {
-	return os.Expand(content, func(key string) string {
-		// 解析 KEY:default 格式
-		parts := strings.SplitN(key, ":", 2)
-		envKey := parts[0]
+// Snapshot 返回默认配置的只读快照
+func Snapshot() *Config {
+	return Default().Snapshot()
+}
 
-		val := os.Getenv(envKey)
-		if val != "" {
-			return val
-		}
+// Name 返回默认配置中的应用名称 app.name，未配置时为 app
+func Name() string {
+	return Default().GetStringWithDefault("app.name", "app")
+}
 
-		// 返回默认值（如果有）
-		if len(parts) == 2 {
-			return parts[1]
-		}
-		return ""
-	})
+// Mode 返回默认配置中的运行模式 app.mode，未配置时为 release
+func Mode() string {
+	return Default().GetStringWithDefault("app.mode", "release")
 }
 
-// Section 获取指定节点的配置（兼容旧 API）
-func Section(name string) map[string]interface{} {
-	if defaultConf == nil {
-		panic("default conf not found.")
-	}
+// SetDefaultConfig 替换默认配置实例，配置值发生变化时通知包级 OnChange 回调
+func SetDefaultConfig(c *Config) {
+	old := defaultConfig.Swap(c)
+	AppName, AppMode = Name(), Mode()
 
-	val, ok := defaultConf[name]
-	if !ok {
-		return map[string]interface{}{}
+	// 首次设置时之前是空配置，同样通知，使 SetDefault 之前注册的回调得到配置
+	oldConf := old.conf()
+	if oldConf == nil {
+		oldConf = map[string]interface{}{}
 	}
+	defaultSubs.notify(oldConf, c.conf())
+}
 
-	section, ok := val.(map[string]interface{})
-	if !ok {
-		return map[string]interface{}{}
+// NewConfig 加载配置文件（含覆盖文件与 include 片段）并替换环境变量，格式由扩展名决定
+func NewConfig(configFile string) map[string]interface{} {
+	st, err := loadState(configFile, newOptions())
+	if err != nil {
+		panic(err)
 	}
+	return st.data
+}
 
-	return section
+// Section 获取指定节点的配置（兼容旧 API）
+func Section(name string) map[string]interface{} {
+	return Default().Section(name)
 }
 
 // Key 获取 app section 下的 key（兼容旧 API）
 func Key(name string) string {
-	appSection := Section("app")
-	val, ok := appSection[name]
-	if !ok {
-		return ""
-	}
-	return fmt.Sprintf("%v", val)
+	return Default().Key(name)
 }
 
 // getValueByPath 通过路径获取配置值
 func getValueByPath(path string) (interface{}, bool) {
-	parts := strings.Split(path, ".")
-	var current interface{} = defaultConf
-
-	for _, part := range parts {
-		switch v := current.(type) {
-		case map[string]interface{}:
-			if next, ok := v[part]; ok {
-				current = next
-			} else {
-				return nil, false
-			}
-		case []interface{}:
-			// 处理数组索引
-			idx := 0
-			if _, err := fmt.Sscanf(part, "%d", &idx); err == nil && idx < len(v) {
-				current = v[idx]
-			} else {
-				return nil, false
-			}
-		default:
-			return nil, false
-		}
-	}
-
-	return current, true
+	return Default().getValueByPath(path)
 }
 
 // GetString 通过路径获取字符串配置值
 func GetString(path string) string {
-	val, ok := getValueByPath(path)
-	if !ok || val == nil {
-		return ""
-	}
-	return fmt.Sprintf("%v", val)
+	return Default().GetString(path)
 }
 
 // GetStringWithDefault 通过路径获取字符串配置值，带默认值
 func GetStringWithDefault(path string, defaultValue string) string {
-	val, ok := getValueByPath(path)
-	if !ok || val == nil {
-		return defaultValue
-	}
-	return fmt.Sprintf("%v", val)
+	return Default().GetStringWithDefault(path, defaultValue)
 }
 
 // GetInt 通过路径获取整数配置值
 func GetInt(path string) int {
-	val, ok := getValueByPath(path)
-	if !ok {
-		return 0
-	}
-
-	switch v := val.(type) {
-	case int:
-		return v
-	case int64:
-		return int(v)
-	case float64:
-		return int(v)
-	default:
-		return 0
-	}
+	return Default().GetInt(path)
 }
 
 // GetIntWithDefault 通过路径获取整数配置值，带默认值
 func GetIntWithDefault(path string, defaultValue int) int {
-	val, ok := getValueByPath(path)
-	if !ok {
-		return defaultValue
-	}
-
-	switch v := val.(type) {
-	case int:
-		return v
-	case int64:
-		return int(v)
-	case float64:
-		return int(v)
-	default:
-		return defaultValue
-	}
+	return Default().GetIntWithDefault(path, defaultValue)
 }
 
 // GetBool 通过路径获取布尔配置值
 func GetBool(path string) bool {
-	val, ok := getValueByPath(path)
-	if !ok {
-		return false
-	}
+	return Default().GetBool(path)
+}
 
-	switch v := val.(type) {
-	case bool:
-		return v
-	default:
-		return false
-	}
+// GetBoolWithDefault 通过路径获取布尔配置值，带默认值
+func GetBoolWithDefault(path string, defaultValue bool) bool {
+	return Default().GetBoolWithDefault(path, defaultValue)
 }
 
 // GetStringMap 通过路径获取 map 类型配置
 func GetStringMap(path string) map[string]interface{} {
-	val, ok := getValueByPath(path)
-	if !ok {
-		return map[string]interface{}{}
-	}
-
-	if m, ok := val.(map[string]interface{}); ok {
-		return m
-	}
-	return map[string]interface{}{}
+	return Default().GetStringMap(path)
 }
 
 // GetSlice 通过路径获取数组类型配置
 func GetSlice(path string) []interface{} {
-	val, ok := getValueByPath(path)
-	if !ok {
-		return []interface{}{}
-	}
+	return Default().GetSlice(path)
+}
 
-	if s, ok := val.([]interface{}); ok {
-		return s
-	}
-	return []interface{}{}
-}
\ No newline at end of file
+// Origin 返回默认配置中该值来自哪个文件
+func Origin(path string) string {
+	return Default().Origin(path)
+}
@@ -3,15 +3,18 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	mysqlDriver "github.com/go-sql-driver/mysql"
 	"github.com/qkzsky/gutils/config"
 	"github.com/qkzsky/gutils/logger"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/plugin/dbresolver"
 	"runtime"
 	"sort"
+	"sync"
 	"time"
 )
 
@@ -24,9 +27,42 @@ var defaultMaxIdle = runtime.NumCPU() + 1
 var defaultMaxOpen = runtime.NumCPU()*2 + 1
 
 var (
-	dbMap = map[string]*gorm.DB{}
+	dbMap     = map[string]*gorm.DB{}
+	gLoggers  []*gLogger
+	watchOnce sync.Once
+	// mu 保护 dbMap 与 gLoggers，配置变更回调与 InitDb 可能并发执行
+	mu sync.RWMutex
 )
 
+func init() {
+	config.AddRule(
+		config.OneOf("database.*.drive", "mysql", "postgres"),
+		config.OneOf("database.*.master.drive", "mysql", "postgres"),
+		config.OneOf("database.*.slaves.*.drive", "mysql", "postgres"),
+		config.Required("database.*.master.drive", "database.*.master.host", "database.*.slaves.*.host"),
+		config.Requires("database.*.slaves", "database.*.master"),
+		config.Range("database.*.master.port", 1, 65535),
+		config.Range("database.*.slaves.*.port", 1, 65535),
+	)
+	config.MarkSecret("database.*.password", "database.*.master.password", "database.*.slaves.*.password")
+
+	connDefaults := map[string]interface{}{
+		"charset":  DefaultCharset,
+		"ssl_mode": DefaultSSLMode,
+		"max_idle": defaultMaxIdle,
+		"max_open": defaultMaxOpen,
+	}
+	config.Defaults(map[string]interface{}{
+		"gorm.trace_sql":      false,
+		"gorm.slow_threshold": "1s",
+		"gorm.prepare_stmt":   true,
+		"database.*.master":   connDefaults,
+		"database.*.slaves.*": connDefaults,
+	})
+	// mysql 驱动的错误日志输出到默认 logger
+	_ = mysqlDriver.SetLogger(logger.NewBridge("mysql", zapcore.ErrorLevel))
+}
+
 type dbConfig struct {
 	Drive    string
 	Host     string
@@ -97,8 +133,54 @@ func InitDb() {
 			panic(fmt.Sprintf("db init failed. name: %s, error: %s.", dbName, err.Error()))
 		}
 
+		mu.Lock()
 		dbMap[dbName] = db
+		mu.Unlock()
 	}
+
+	watchOnce.Do(watchConfig)
+}
+
+// watchConfig 配置重新加载时实时调整 gorm 日志参数与主库连接池大小
+func watchConfig() {
+	config.OnChange("gorm", func(_, _ interface{}) {
+		gormSC := config.GetStringMap("gorm")
+		mu.RLock()
+		loggers := append([]*gLogger(nil), gLoggers...)
+		mu.RUnlock()
+		for _, l := range loggers {
+			l.SetTraceSQL(getBoolFromMapWithDefault(gormSC, "trace_sql", false))
+			l.SetSlowThreshold(getDurationFromMapWithDefault(gormSC, "slow_threshold", 1*time.Second))
+		}
+	})
+
+	config.OnChange("database", func(_, _ interface{}) {
+		dbConfigMap := config.GetStringMap("database")
+		mu.RLock()
+		dbs := make(map[string]*gorm.DB, len(dbMap))
+		for dbName, db := range dbMap {
+			dbs[dbName] = db
+		}
+		mu.RUnlock()
+		for dbName, db := range dbs {
+			confMap, ok := dbConfigMap[dbName].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if masterConf, ok := confMap["master"].(map[string]interface{}); ok {
+				confMap = masterConf
+			}
+
+			c := parseDbConfig(confMap, true)
+			sqlDB, err := db.DB()
+			if err != nil {
+				logger.Error(fmt.Sprintf("db pool resize failed. name: %s, error: %s", dbName, err.Error()))
+				continue
+			}
+			sqlDB.SetMaxIdleConns(c.MaxIdle)
+			sqlDB.SetMaxOpenConns(c.MaxOpen)
+		}
+	})
 }
 
 func parseDbConfig(conf map[string]interface{}, isMaster bool) *dbConfig {
@@ -119,14 +201,19 @@ func parseDbConfig(conf map[string]interface{}, isMaster bool) *dbConfig {
 
 func makeDB(cs dbConfigList) (DB *gorm.DB, err error) {
 	gormSC := config.GetStringMap("gorm")
+	gl := newGLogger(
+		logger.GetDefaultLogger().WithOptions(zap.AddCallerSkip(1)),
+		getBoolFromMapWithDefault(gormSC, "trace_sql", false),
+		getDurationFromMapWithDefault(gormSC, "slow_threshold", 1*time.Second),
+	)
+	mu.Lock()
+	gLoggers = append(gLoggers, gl)
+	mu.Unlock()
+
 	var gormConfig = &gorm.Config{
 		SkipDefaultTransaction: true,
 		PrepareStmt:            getBoolFromMapWithDefault(gormSC, "prepare_stmt", true),
-		Logger: &gLogger{
-			Logger:        logger.GetDefaultLogger().WithOptions(zap.AddCallerSkip(1)),
-			TraceSQL:      getBoolFromMapWithDefault(gormSC, "trace_sql", false),
-			SlowThreshold: getDurationFromMapWithDefault(gormSC, "slow_threshold", 1*time.Second),
-		},
+		Logger:                 gl,
 	}
 
 	DB, err = gorm.Open(NewDialector(cs[0]), gormConfig)
@@ -170,7 +257,10 @@ func makeDB(cs dbConfigList) (DB *gorm.DB, err error) {
 }
 
 func GetDB(name string) *gorm.DB {
-	if client, ok := dbMap[name]; ok {
+	mu.RLock()
+	client, ok := dbMap[name]
+	mu.RUnlock()
+	if ok {
 		return client
 	}
 
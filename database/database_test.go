@@ -0,0 +1,52 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qkzsky/gutils/config"
+)
+
+func TestFlatConfigDefaults(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(`
+database:
+  simple:
+    drive: mysql
+    host: 127.0.0.1
+    port: 3306
+  cluster:
+    master:
+      drive: mysql
+      host: 127.0.0.1
+    slaves:
+      - host: 127.0.0.2
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := config.Load(file)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate error: %v", err)
+	}
+	if c.IsSet("database.simple.master") || c.IsSet("database.simple.slaves") {
+		t.Error("flat config must not get a master or slaves section")
+	}
+	if c.GetString("database.cluster.master.charset") != DefaultCharset || c.GetString("database.cluster.slaves.0.charset") != DefaultCharset {
+		t.Error("expected connection defaults for master and slaves")
+	}
+
+	// 简单配置按主库解析
+	conf, _ := c.GetStringMap("database")["simple"].(map[string]interface{})
+	if _, ok := conf["master"]; ok {
+		t.Fatal("unexpected master")
+	}
+	if db := parseDbConfig(conf, true); db.Host != "127.0.0.1" || db.Drive != "mysql" || db.Charset != DefaultCharset {
+		t.Errorf("unexpected config %+v", db)
+	}
+}
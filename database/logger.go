@@ -5,14 +5,32 @@ import (
 	"fmt"
 	"go.uber.org/zap"
 	gormLogger "gorm.io/gorm/logger"
+	"sync/atomic"
 	"time"
 )
 
 type gLogger struct {
 	*zap.Logger
 	level         gormLogger.LogLevel
-	TraceSQL      bool
-	SlowThreshold time.Duration
+	traceSQL      atomic.Bool
+	slowThreshold atomic.Int64
+}
+
+func newGLogger(l *zap.Logger, traceSQL bool, slowThreshold time.Duration) *gLogger {
+	gl := &gLogger{Logger: l}
+	gl.SetTraceSQL(traceSQL)
+	gl.SetSlowThreshold(slowThreshold)
+	return gl
+}
+
+// SetTraceSQL 设置是否记录所有 SQL，可在运行时调整
+func (l *gLogger) SetTraceSQL(trace bool) {
+	l.traceSQL.Store(trace)
+}
+
+// SetSlowThreshold 设置慢查询阈值，可在运行时调整
+func (l *gLogger) SetSlowThreshold(d time.Duration) {
+	l.slowThreshold.Store(int64(d))
 }
 
 func (l *gLogger) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
@@ -34,7 +52,8 @@ func (l *gLogger) Error(ctx context.Context, msg string, data ...interface{}) {
 
 func (l *gLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
 	elapsed := time.Since(begin)
-	if !l.TraceSQL && err == nil && !(l.SlowThreshold != 0 && elapsed > l.SlowThreshold) {
+	traceSQL, slowThreshold := l.traceSQL.Load(), time.Duration(l.slowThreshold.Load())
+	if !traceSQL && err == nil && !(slowThreshold != 0 && elapsed > slowThreshold) {
 		return
 	}
 
@@ -51,10 +70,10 @@ func (l *gLogger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 		logFields = append(logFields, zap.Error(err))
 		l.Logger.Error("[gorm] Trace Error", logFields...)
 	//case l.SlowThreshold != 0 && elapsed > l.SlowThreshold && l.level >= gormLogger.Warn:
-	case l.SlowThreshold != 0 && elapsed > l.SlowThreshold:
+	case slowThreshold != 0 && elapsed > slowThreshold:
 		l.Logger.Warn("[gorm] Trace Slow SQL", logFields...)
 	//case l.level >= gormLogger.Info:
-	case l.TraceSQL:
+	case traceSQL:
 		l.Logger.Info("[gorm] Trace", logFields...)
 	}
 }
@@ -0,0 +1,177 @@
+// Package feature 基于配置的功能开关
+//
+//	feature:
+//	  new_checkout: true          # 布尔开关
+//	  search_v2:
+//	    enabled: true             # 总开关，默认 true
+//	    percentage: 20            # 按用户（无用户时按租户）灰度的百分比 0-100
+//	    users: [u1, u2]           # 白名单，命中即开启
+//	    tenants: [t1]
+//	    deny_users: [u3]          # 黑名单，优先于白名单与灰度
+//	    deny_tenants: [t9]
+//
+// 配置重新加载后开关实时生效
+package feature
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/qkzsky/gutils/config"
+	"github.com/qkzsky/gutils/logger"
+	"go.uber.org/zap"
+)
+
+// Section 功能开关所在的配置节点
+const Section = "feature"
+
+// Flag 单个功能开关的规则
+type Flag struct {
+	Enabled     bool     `yaml:"enabled" default:"true"`
+	Percentage  *float64 `yaml:"percentage"`
+	Users       []string `yaml:"users"`
+	Tenants     []string `yaml:"tenants"`
+	DenyUsers   []string `yaml:"deny_users"`
+	DenyTenants []string `yaml:"deny_tenants"`
+}
+
+type ctxKey int
+
+const (
+	userKey ctxKey = iota
+	tenantKey
+)
+
+var (
+	flags    atomic.Pointer[map[string]*Flag]
+	initOnce sync.Once
+)
+
+func init() {
+	config.AddRule(config.Range(Section+".*.percentage", 0, 100))
+}
+
+// WithUser 在 context 中设置用户 ID，用于名单匹配与灰度
+func WithUser(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userKey, id)
+}
+
+// WithTenant 在 context 中设置租户 ID，用于名单匹配与灰度
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey, id)
+}
+
+// Enabled 判断功能是否对 ctx 中的用户/租户开启，未配置的功能视为关闭
+// 同一用户对同一功能的灰度结果固定，调整百分比时已开启的用户保持开启
+func Enabled(ctx context.Context, name string) bool {
+	initOnce.Do(initFlags)
+
+	user, _ := ctx.Value(userKey).(string)
+	tenant, _ := ctx.Value(tenantKey).(string)
+
+	f := (*flags.Load())[name]
+	enabled, reason := f.evaluate(name, user, tenant)
+	if l := logger.GetDefaultLogger(); l != nil {
+		if ce := l.Check(zap.DebugLevel, "[feature] evaluate"); ce != nil {
+			ce.Write(
+				zap.String("feature", name),
+				zap.Bool("enabled", enabled),
+				zap.String("reason", reason),
+				zap.String("user", user),
+				zap.String("tenant", tenant),
+			)
+		}
+	}
+	return enabled
+}
+
+// Get 返回功能开关的规则，未配置时返回 nil
+func Get(name string) *Flag {
+	initOnce.Do(initFlags)
+	return (*flags.Load())[name]
+}
+
+// initFlags 首次使用时加载开关并监听配置变更，SetDefault 之前使用时在首次加载配置后生效
+func initFlags() {
+	reload()
+	config.OnChange(Section, func(_, _ interface{}) {
+		reload()
+	})
+}
+
+// evaluate 返回判断结果及原因
+func (f *Flag) evaluate(name, user, tenant string) (bool, string) {
+	switch {
+	case f == nil:
+		return false, "undefined"
+	case !f.Enabled:
+		return false, "disabled"
+	case user != "" && contains(f.DenyUsers, user):
+		return false, "deny_users"
+	case tenant != "" && contains(f.DenyTenants, tenant):
+		return false, "deny_tenants"
+	case user != "" && contains(f.Users, user):
+		return true, "users"
+	case tenant != "" && contains(f.Tenants, tenant):
+		return true, "tenants"
+	case f.Percentage != nil:
+		return rollout(name, user, tenant, *f.Percentage), "percentage"
+	case len(f.Users) > 0 || len(f.Tenants) > 0:
+		return false, "not_listed"
+	default:
+		return true, "enabled"
+	}
+}
+
+// rollout 按 fnv 哈希将用户稳定地映射到 [0, 100) 中，小于百分比时开启
+func rollout(name, user, tenant string, percentage float64) bool {
+	id := user
+	if id == "" {
+		id = tenant
+	}
+	if id == "" {
+		return percentage >= 100
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name + ":" + id))
+	return float64(h.Sum32()%10000)/100 < percentage
+}
+
+// reload 从默认配置解析全部功能开关，解析失败的开关视为关闭
+func reload() {
+	snap := config.Snapshot()
+	parsed := map[string]*Flag{}
+	for name, val := range snap.GetStringMap(Section) {
+		path := Section + "." + name
+		f := &Flag{}
+		if _, ok := val.(map[string]interface{}); ok {
+			if err := snap.UnmarshalKey(path, f); err != nil {
+				logError(fmt.Sprintf("[feature] invalid flag %s: %s", name, err))
+				continue
+			}
+		} else {
+			f.Enabled = snap.GetBool(path)
+		}
+		parsed[name] = f
+	}
+	flags.Store(&parsed)
+}
+
+func logError(msg string) {
+	if l := logger.GetDefaultLogger(); l != nil {
+		l.Error(msg)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
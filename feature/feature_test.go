@@ -0,0 +1,173 @@
+package feature
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/qkzsky/gutils/config"
+)
+
+func setupConfig(t *testing.T, content string) string {
+	t.Helper()
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Init(file); err != nil {
+		t.Fatalf("Init error: %v", err)
+	}
+	return file
+}
+
+func TestEnabled(t *testing.T) {
+	setupConfig(t, `
+feature:
+  on: true
+  off: false
+  env_on: "${MISSING:-true}"
+  disabled:
+    enabled: false
+    users: [u1]
+  listed:
+    users: [u1, u2]
+    tenants: t1, t2
+    deny_users: [u2]
+  denied_tenant:
+    deny_tenants: [t9]
+  half:
+    percentage: 50
+  all:
+    percentage: 100
+`)
+	bg := context.Background()
+	u1, u2, u3 := WithUser(bg, "u1"), WithUser(bg, "u2"), WithUser(bg, "u3")
+
+	tests := []struct {
+		ctx      context.Context
+		name     string
+		expected bool
+	}{
+		{bg, "on", true},
+		{bg, "off", false},
+		{bg, "env_on", true},
+		{bg, "undefined", false},
+		{u1, "disabled", false},
+		{u1, "listed", true},
+		{u2, "listed", false},
+		{u3, "listed", false},
+		{WithTenant(u3, "t2"), "listed", true},
+		{WithTenant(u1, "t9"), "denied_tenant", false},
+		{WithTenant(u1, "t1"), "denied_tenant", true},
+		{bg, "half", false},
+		{bg, "all", true},
+		{u3, "all", true},
+	}
+	for _, tt := range tests {
+		if got := Enabled(tt.ctx, tt.name); got != tt.expected {
+			t.Errorf("Enabled(%s, user=%v) expected %v, got %v", tt.name, tt.ctx.Value(userKey), tt.expected, got)
+		}
+	}
+}
+
+func TestRollout(t *testing.T) {
+	setupConfig(t, "feature:\n  half:\n    percentage: 30\n")
+	reload()
+
+	on := 0
+	for i := 0; i < 10000; i++ {
+		ctx := WithUser(context.Background(), fmt.Sprintf("user-%d", i))
+		enabled := Enabled(ctx, "half")
+		if enabled != Enabled(ctx, "half") {
+			t.Fatalf("rollout must be deterministic")
+		}
+		if enabled {
+			on++
+		}
+	}
+	if on < 2700 || on > 3300 {
+		t.Errorf("30%% rollout expected about 3000 of 10000, got %d", on)
+	}
+
+	// 提高百分比时已开启的用户保持开启
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("user-%d", i)
+		if rollout("half", id, "", 30) && !rollout("half", id, "", 60) {
+			t.Fatalf("%s lost the feature when percentage increased", id)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	file := setupConfig(t, "feature:\n  beta: false\n")
+	reload()
+	ctx := context.Background()
+	if Enabled(ctx, "beta") {
+		t.Fatalf("beta expected disabled")
+	}
+
+	if err := os.WriteFile(file, []byte("feature:\n  beta: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+	if !Enabled(ctx, "beta") {
+		t.Errorf("beta expected enabled after reload")
+	}
+	if f := Get("beta"); f == nil || !f.Enabled {
+		t.Errorf("Get(beta) expected enabled flag, got %+v", f)
+	}
+}
+
+func TestInvalidPercentage(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("feature:\n  x:\n    percentage: 150\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Init(file); err == nil {
+		t.Errorf("Init expected validation error for percentage out of range")
+	}
+}
+
+func TestGetWatchesConfig(t *testing.T) {
+	file := setupConfig(t, "feature:\n  gamma: false\n")
+	// Get 先于 Enabled 调用时同样监听配置变更
+	initOnce = sync.Once{}
+	if f := Get("gamma"); f == nil || f.Enabled {
+		t.Fatalf("Get(gamma) expected disabled flag, got %+v", f)
+	}
+
+	if err := os.WriteFile(file, []byte("feature:\n  gamma: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Reload(); err != nil {
+		t.Fatalf("Reload error: %v", err)
+	}
+	if f := Get("gamma"); f == nil || !f.Enabled {
+		t.Errorf("Get(gamma) expected enabled after reload, got %+v", f)
+	}
+}
+
+func TestEnabledBeforeSetDefault(t *testing.T) {
+	old := config.Default()
+	defer config.SetDefaultConfig(old)
+
+	// SetDefault 之前调用 Enabled，首次加载配置后开关生效
+	config.SetDefaultConfig(&config.Config{})
+	initOnce = sync.Once{}
+	ctx := context.Background()
+	if Enabled(ctx, "early") {
+		t.Fatalf("early expected disabled before config is loaded")
+	}
+
+	setupConfig(t, "feature:\n  early: true\n")
+	if !Enabled(ctx, "early") {
+		t.Errorf("early expected enabled after SetDefault")
+	}
+}
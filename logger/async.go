@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 异步输出缓冲区满时的处理方式
+const (
+	// OverflowBlock 阻塞等待，不丢弃日志
+	OverflowBlock = "block"
+	// OverflowDropNewest 丢弃新日志
+	OverflowDropNewest = "drop_newest"
+	// OverflowDropOldest 丢弃缓冲区中最早的日志
+	OverflowDropOldest = "drop_oldest"
+)
+
+// asyncWriteBufferSize 异步输出写入底层 writer 前的合并缓冲区大小
+const asyncWriteBufferSize = 256 << 10
+
+// 异步输出配置无效时使用的默认值
+const (
+	defaultAsyncBufferSize    = 8192
+	defaultAsyncFlushInterval = time.Second
+)
+
+// AsyncConfig log.async 异步输出配置
+type AsyncConfig struct {
+	// Enabled 是否异步输出，log.outputs 中的 async 可以单独覆盖，syslog 输出始终同步
+	Enabled bool `yaml:"enabled"`
+	// BufferSize 缓冲的日志条数
+	BufferSize int `yaml:"buffer_size" default:"8192"`
+	// FlushInterval 定期写入底层输出的间隔
+	FlushInterval time.Duration `yaml:"flush_interval" default:"1s"`
+	// Overflow 缓冲区满时的处理方式：block/drop_newest/drop_oldest
+	Overflow string `yaml:"overflow" default:"block"`
+}
+
+// dropped 所有异步、网络与 OTLP 输出丢弃的日志条数
+var dropped atomic.Uint64
+
+// Dropped 返回异步输出因缓冲区满、网络输出因断开时缓存已满、OTLP 因发送失败而丢弃的日志条数
+func Dropped() uint64 {
+	return dropped.Load()
+}
+
+// asyncWriter 将日志写入有界队列，由后台 goroutine 合并写入底层输出
+// Sync 等待队列中的日志全部写入并同步底层输出；Close 之后的写入直接同步写入底层输出
+type asyncWriter struct {
+	ws       zapcore.WriteSyncer
+	closer   io.Closer
+	overflow string
+
+	queue   chan []byte
+	flushCh chan chan error
+	done    chan struct{}
+
+	// mu 保护 closed，写入持有读锁，避免 Close 后向已关闭的队列写入
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newAsyncWriter(ws zapcore.WriteSyncer, closer io.Closer, cfg AsyncConfig) *asyncWriter {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultAsyncBufferSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultAsyncFlushInterval
+	}
+	w := &asyncWriter{
+		ws:       ws,
+		closer:   closer,
+		overflow: cfg.Overflow,
+		queue:    make(chan []byte, cfg.BufferSize),
+		flushCh:  make(chan chan error),
+		done:     make(chan struct{}),
+	}
+	go w.run(cfg.FlushInterval)
+	return w
+}
+
+func (w *asyncWriter) run(interval time.Duration) {
+	defer close(w.done)
+
+	buf := bufio.NewWriterSize(w.ws, asyncWriteBufferSize)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case p, ok := <-w.queue:
+			if !ok {
+				_ = buf.Flush()
+				return
+			}
+			_, _ = buf.Write(p)
+		case <-ticker.C:
+			_ = buf.Flush()
+		case ch := <-w.flushCh:
+			// 写入 Sync 之前已入队的日志
+			for n := len(w.queue); n > 0; n-- {
+				_, _ = buf.Write(<-w.queue)
+			}
+			err := buf.Flush()
+			ch <- errors.Join(err, w.ws.Sync())
+		}
+	}
+}
+
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return w.ws.Write(p)
+	}
+
+	// zap 在 Write 返回后会复用 p
+	entry := append([]byte(nil), p...)
+	switch w.overflow {
+	case OverflowDropNewest:
+		select {
+		case w.queue <- entry:
+		default:
+			dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- entry:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		w.queue <- entry
+	}
+	return len(p), nil
+}
+
+func (w *asyncWriter) Sync() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return w.ws.Sync()
+	}
+	ch := make(chan error, 1)
+	w.flushCh <- ch
+	return <-ch
+}
+
+// Close 写入队列中的全部日志后关闭底层输出
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	<-w.done
+	err := w.ws.Sync()
+	if w.closer != nil {
+		err = errors.Join(err, w.closer.Close())
+	}
+	return err
+}
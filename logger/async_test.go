@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter 在 block 关闭前阻塞写入，用于模拟底层输出变慢
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	entered chan struct{}
+	block   chan struct{}
+	once    sync.Once
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{entered: make(chan struct{}), block: make(chan struct{})}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.entered) })
+	<-w.block
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) Sync() error { return nil }
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	for _, tc := range []struct {
+		overflow string
+		expected string
+		dropped  uint64
+	}{
+		{OverflowDropNewest, "a\nb\nc\n", 2},
+		{OverflowDropOldest, "a\nd\ne\n", 2},
+	} {
+		t.Run(tc.overflow, func(t *testing.T) {
+			ws := newBlockingWriter()
+			w := newAsyncWriter(ws, nil, AsyncConfig{BufferSize: 2, FlushInterval: time.Hour, Overflow: tc.overflow})
+			defer w.Close()
+
+			_, _ = w.Write([]byte("a\n"))
+			// Sync 使后台 goroutine 阻塞在底层写入上，之后的日志只能进入队列
+			synced := make(chan error, 1)
+			go func() { synced <- w.Sync() }()
+			<-ws.entered
+
+			before := Dropped()
+			for _, s := range []string{"b\n", "c\n", "d\n", "e\n"} {
+				if _, err := w.Write([]byte(s)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if d := Dropped() - before; d != tc.dropped {
+				t.Errorf("expected %d dropped, got %d", tc.dropped, d)
+			}
+
+			close(ws.block)
+			if err := <-synced; err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Sync(); err != nil {
+				t.Fatal(err)
+			}
+			if got := ws.String(); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestAsyncWriterClose(t *testing.T) {
+	ws := newBlockingWriter()
+	close(ws.block)
+	w := newAsyncWriter(ws, nil, AsyncConfig{BufferSize: 16, FlushInterval: time.Hour, Overflow: OverflowBlock})
+
+	for _, s := range []string{"a\n", "b\n"} {
+		_, _ = w.Write([]byte(s))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ws.String(); got != "a\nb\n" {
+		t.Errorf("expected queued entries flushed on close, got %q", got)
+	}
+
+	// 关闭后同步写入
+	_, _ = w.Write([]byte("c\n"))
+	if got := ws.String(); got != "a\nb\nc\n" {
+		t.Errorf("expected synchronous write after close, got %q", got)
+	}
+}
+
+func TestAsyncWriterInvalidConfig(t *testing.T) {
+	ws := newBlockingWriter()
+	close(ws.block)
+	// flush_interval、buffer_size 不为正数时使用默认值
+	w := newAsyncWriter(ws, nil, AsyncConfig{BufferSize: -1, FlushInterval: 0, Overflow: OverflowBlock})
+	_, _ = w.Write([]byte("a\n"))
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ws.String(); got != "a\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestAsyncLogger(t *testing.T) {
+	dir := useTestLogPath(t)
+	useTestConfig(t, `
+log:
+  async:
+    enabled: true
+    flush_interval: 1h
+  outputs:
+    - filename: "{name}.log"
+    - filename: "{name}.sync.log"
+      async: false
+`)
+	l := NewLogger("async")
+	l.Info("buffered")
+
+	if content, _ := os.ReadFile(filepath.Join(dir, "async.log")); len(content) != 0 {
+		t.Errorf("expected async output buffered, got %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "async.sync.log")); !strings.Contains(string(content), "buffered") {
+		t.Errorf("expected sync output written, got %q", content)
+	}
+
+	_ = Sync()
+	if content, _ := os.ReadFile(filepath.Join(dir, "async.log")); !strings.Contains(string(content), "buffered") {
+		t.Errorf("expected async output flushed by Sync, got %q", content)
+	}
+
+	l.Info("before close")
+	if err := Close("async"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "async.log")); !strings.Contains(string(content), "before close") {
+		t.Errorf("expected async output flushed by Close, got %q", content)
+	}
+	mu.RLock()
+	_, ok := loggerMap["async"]
+	mu.RUnlock()
+	if ok {
+		t.Error("expected closed logger removed from loggerMap")
+	}
+}
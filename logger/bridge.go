@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// bridgeCache 第三方日志使用的 logger，按默认 logger 缓存，InitLogger 替换默认 logger 后重新创建
+type bridgeCache struct {
+	base    *zap.Logger
+	loggers sync.Map // bridgeKey -> *zap.Logger
+}
+
+type bridgeKey struct {
+	name string
+	skip int
+}
+
+var bridges atomic.Pointer[bridgeCache]
+
+// bridgeFallback 默认 logger 未初始化时第三方日志输出到 stderr，不使用标准库的默认 logger，避免 RedirectStdLog 后循环输出
+var bridgeFallback = log.New(os.Stderr, "", log.LstdFlags)
+
+// bridgeLogger 第三方日志使用的 logger，InitLogger 重新初始化后依然有效，默认 logger 未初始化时返回 nil
+// 默认 logger 为包级函数跳过了一层调用栈，skip 为适配器自身的调用层数
+func bridgeLogger(name string, skip int) *zap.Logger {
+	base := defaultLogger.Load()
+	if base == nil {
+		return nil
+	}
+
+	c := bridges.Load()
+	if c == nil || c.base != base {
+		c = &bridgeCache{base: base}
+		bridges.Store(c)
+	}
+	key := bridgeKey{name: name, skip: skip}
+	if l, ok := c.loggers.Load(key); ok {
+		return l.(*zap.Logger)
+	}
+	l, _ := c.loggers.LoadOrStore(key, base.WithOptions(zap.AddCallerSkip(skip-1)).Named(name))
+	return l.(*zap.Logger)
+}
+
+// Bridge 第三方库日志的适配器，输出到默认 logger，logger 名称为 name
+//
+//	redis.SetLogger(logger.NewBridge("redis", zapcore.WarnLevel))  // go-redis internal.Logging
+//	mysql.SetLogger(logger.NewBridge("mysql", zapcore.ErrorLevel)) // go-sql-driver/mysql Logger
+type Bridge struct {
+	name  string
+	level zapcore.Level
+}
+
+// NewBridge 创建适配器，日志统一以 level 级别输出
+// 默认 logger 未初始化时 info 及以上级别的日志输出到 stderr
+func NewBridge(name string, level zapcore.Level) *Bridge {
+	return &Bridge{name: name, level: level}
+}
+
+// Print 实现 mysql.Logger
+func (b *Bridge) Print(v ...interface{}) {
+	b.write(nil, fmt.Sprint(v...))
+}
+
+// Printf 实现 go-redis 的 internal.Logging，附带 context 中的追踪 ID 等字段
+func (b *Bridge) Printf(ctx context.Context, format string, v ...interface{}) {
+	b.write(ctx, fmt.Sprintf(format, v...))
+}
+
+func (b *Bridge) write(ctx context.Context, msg string) {
+	l := bridgeLogger(b.name, 2)
+	if l == nil {
+		if b.level >= zapcore.InfoLevel {
+			bridgeFallback.Printf("%s [%s] %s", b.level.CapitalString(), b.name, strings.TrimRight(msg, "\n"))
+		}
+		return
+	}
+	if ce := l.Check(b.level, strings.TrimRight(msg, "\n")); ce != nil {
+		ce.Write(ContextFields(ctx)...)
+	}
+}
+
+// stdLogWriter 标准库 log 的输出，每行日志以 info 级别写入默认 logger，默认 logger 未初始化时输出到 stderr
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	// 调用栈：Write <- log.(*Logger).output <- log.Println 等 <- 调用方
+	msg := strings.TrimRight(string(p), "\n")
+	if l := bridgeLogger("stdlog", 3); l != nil {
+		l.Info(msg)
+	} else {
+		bridgeFallback.Print(msg)
+	}
+	return len(p), nil
+}
+
+var stdLogMu sync.Mutex
+
+// RedirectStdLog 将标准库 log 的输出重定向到默认 logger，返回恢复原输出的函数
+// 日志的 logger 名称为 stdlog，InitLogger 重新初始化后依然有效
+func RedirectStdLog() func() {
+	stdLogMu.Lock()
+	defer stdLogMu.Unlock()
+
+	flags, prefix, writer := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(stdLogWriter{})
+	return func() {
+		stdLogMu.Lock()
+		defer stdLogMu.Unlock()
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(writer)
+	}
+}
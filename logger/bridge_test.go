@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	buf := useTestLogger(t, zapcore.NewJSONEncoder(GetEncoder()))
+	restore := RedirectStdLog()
+	log.Println("curl:", "timeout")
+	restore()
+	if _, ok := log.Writer().(stdLogWriter); ok {
+		t.Error("expected std log restored")
+	}
+
+	m := decodeLine(t, buf.String())
+	if m["msg"] != "curl: timeout" || m["level"] != "info" || m["logger"] != "stdlog" {
+		t.Errorf("unexpected log %v", m)
+	}
+	if caller, _ := m["caller"].(string); !strings.HasPrefix(caller, "logger/bridge_test.go") {
+		t.Errorf("expected caller in test, got %v", m["caller"])
+	}
+}
+
+func TestBridge(t *testing.T) {
+	buf := useTestLogger(t, zapcore.NewJSONEncoder(GetEncoder()))
+
+	NewBridge("redis", zapcore.WarnLevel).Printf(WithTraceID(context.Background(), "trace-1"), "redis: pool %s\n", "exhausted")
+	NewBridge("mysql", zapcore.ErrorLevel).Print("[mysql] ", "invalid connection")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	redis, mysql := decodeLine(t, lines[0]), decodeLine(t, lines[1])
+	if redis["msg"] != "redis: pool exhausted" || redis["level"] != "warn" || redis["logger"] != "redis" || redis[TraceIDKey] != "trace-1" {
+		t.Errorf("unexpected redis log %v", redis)
+	}
+	if mysql["msg"] != "[mysql] invalid connection" || mysql["level"] != "error" || mysql["logger"] != "mysql" {
+		t.Errorf("unexpected mysql log %v", mysql)
+	}
+	for _, m := range []map[string]interface{}{redis, mysql} {
+		if caller, _ := m["caller"].(string); !strings.HasPrefix(caller, "logger/bridge_test.go") {
+			t.Errorf("expected caller in test, got %v", m["caller"])
+		}
+	}
+
+	// 同一默认 logger 下复用创建的 logger，默认 logger 替换后重新创建
+	if bridgeLogger("redis", 2) != bridgeLogger("redis", 2) {
+		t.Error("expected cached bridge logger")
+	}
+	cached := bridgeLogger("redis", 2)
+	useTestLogger(t, zapcore.NewJSONEncoder(GetEncoder()))
+	if bridgeLogger("redis", 2) == cached {
+		t.Error("expected bridge logger recreated for new default logger")
+	}
+}
+
+func TestBridgeFallback(t *testing.T) {
+	useTestLogger(t, zapcore.NewJSONEncoder(GetEncoder()))
+	defaultLogger.Store(nil)
+	var buf bytes.Buffer
+	bridgeFallback.SetOutput(&buf)
+	defer bridgeFallback.SetOutput(os.Stderr)
+
+	// 默认 logger 未初始化时输出到 stderr
+	NewBridge("redis", zapcore.WarnLevel).Print("pool exhausted\n")
+	NewBridge("redis", zapcore.DebugLevel).Print("ignored")
+	restore := RedirectStdLog()
+	log.Println("curl: timeout")
+	restore()
+
+	out := buf.String()
+	if !strings.Contains(out, "WARN [redis] pool exhausted\n") || !strings.Contains(out, "curl: timeout\n") || strings.Contains(out, "ignored") {
+		t.Errorf("unexpected fallback output %q", out)
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := useTestLogger(t, zapcore.NewJSONEncoder(GetEncoder()))
+	l := slog.New(NewSlogHandler(nil)).With("app", "gutils").WithGroup("req")
+
+	ctx := WithTraceID(context.Background(), "trace-1")
+	l.DebugContext(ctx, "debug")
+	l.Log(ctx, slog.LevelWarn+1, "slow", "cost", 120, slog.Group("db", "table", "users", slog.Group("empty")), "err", errors.New("boom"))
+	l.WithGroup("empty").Info("no attrs")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+	if m := decodeLine(t, lines[0]); m["level"] != "debug" || m[TraceIDKey] != "trace-1" || m["app"] != "gutils" {
+		t.Errorf("unexpected debug log %v", m)
+	}
+
+	m := decodeLine(t, lines[1])
+	req, _ := m["req"].(map[string]interface{})
+	db, _ := req["db"].(map[string]interface{})
+	if m["level"] != "warn" || m["msg"] != "slow" || req["cost"] != float64(120) || req["err"] != "boom" || db["table"] != "users" {
+		t.Errorf("unexpected warn log %v", m)
+	}
+	if _, ok := db["empty"]; ok {
+		t.Errorf("expected empty group omitted, got %v", db)
+	}
+	if caller, _ := m["caller"].(string); !strings.HasPrefix(caller, "logger/bridge_test.go") {
+		t.Errorf("expected caller in test, got %v", m["caller"])
+	}
+
+	if m := decodeLine(t, lines[2]); m["req"] != nil || m["msg"] != "no attrs" {
+		t.Errorf("expected no empty group, got %v", m)
+	}
+
+	if slogLevel(slog.LevelError+4) != zapcore.ErrorLevel || slogLevel(slog.LevelDebug-4) != zapcore.DebugLevel {
+		t.Error("unexpected level mapping")
+	}
+}
+
+func TestSlogHandlerBeforeInit(t *testing.T) {
+	useTestLogger(t, zapcore.NewJSONEncoder(GetEncoder()))
+	defaultLogger.Store(nil)
+	var fallback bytes.Buffer
+	bridgeFallback.SetOutput(&fallback)
+	defer bridgeFallback.SetOutput(os.Stderr)
+
+	// InitLogger 之前创建的 handler 输出到 stderr，初始化后输出到默认 logger
+	l := slog.New(NewSlogHandler(nil)).With("app", "gutils")
+	l.Info("before init")
+	if !strings.Contains(fallback.String(), "INFO before init") {
+		t.Errorf("unexpected fallback output %q", fallback.String())
+	}
+
+	buf := useTestLogger(t, zapcore.NewJSONEncoder(GetEncoder()))
+	l.Info("after init")
+	if m := decodeLine(t, buf.String()); m["msg"] != "after init" || m["app"] != "gutils" {
+		t.Errorf("unexpected log %v", m)
+	}
+}
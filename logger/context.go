@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// TraceIDKey 链路追踪 ID 的字段名
+	TraceIDKey = "trace_id"
+	// SpanIDKey 链路追踪 span ID 的字段名
+	SpanIDKey = "span_id"
+	// RequestIDKey 请求 ID 的字段名，MIS 格式优先使用它作为 logID
+	RequestIDKey = "request_id"
+)
+
+type ctxKey int
+
+const (
+	fieldsKey ctxKey = iota
+	traceIDKey
+	spanIDKey
+	requestIDKey
+)
+
+// TraceExtractor 从 context 中提取链路追踪 ID，用于接入 OpenTelemetry 等追踪系统
+type TraceExtractor func(ctx context.Context) (traceID, spanID string)
+
+var traceExtractor atomic.Pointer[TraceExtractor]
+
+// SetTraceExtractor 设置链路追踪 ID 的提取方式，context 中未通过 WithTraceID 设置时使用
+//
+//	logger.SetTraceExtractor(func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	})
+func SetTraceExtractor(fn TraceExtractor) {
+	traceExtractor.Store(&fn)
+}
+
+// WithContext 返回附带日志字段的 context，之后通过 FromContext 或 *Ctx 函数输出的日志都会带上这些字段
+func WithContext(ctx context.Context, fields ...zap.Field) context.Context {
+	parent, _ := ctx.Value(fieldsKey).([]zap.Field)
+	merged := make([]zap.Field, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey, merged)
+}
+
+// WithTraceID 在 context 中设置链路追踪 ID
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey, id)
+}
+
+// WithSpanID 在 context 中设置 span ID
+func WithSpanID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, spanIDKey, id)
+}
+
+// WithRequestID 在 context 中设置请求 ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// TraceID 返回 context 中的链路追踪 ID
+func TraceID(ctx context.Context) string {
+	traceID, _ := traceIDs(ctx)
+	return traceID
+}
+
+// SpanID 返回 context 中的 span ID
+func SpanID(ctx context.Context) string {
+	_, spanID := traceIDs(ctx)
+	return spanID
+}
+
+// RequestID 返回 context 中的请求 ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func traceIDs(ctx context.Context) (traceID, spanID string) {
+	traceID, _ = ctx.Value(traceIDKey).(string)
+	spanID, _ = ctx.Value(spanIDKey).(string)
+	if traceID != "" {
+		return
+	}
+	if fn := traceExtractor.Load(); fn != nil {
+		return (*fn)(ctx)
+	}
+	return
+}
+
+// ContextFields 返回 context 中的追踪 ID 与 WithContext 附带的字段
+func ContextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	var fields []zap.Field
+	traceID, spanID := traceIDs(ctx)
+	if traceID != "" {
+		fields = append(fields, zap.String(TraceIDKey, traceID))
+	}
+	if spanID != "" {
+		fields = append(fields, zap.String(SpanIDKey, spanID))
+	}
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, zap.String(RequestIDKey, id))
+	}
+	extra, _ := ctx.Value(fieldsKey).([]zap.Field)
+	return append(fields, extra...)
+}
+
+// FromContext 返回附带 context 字段的默认 logger，默认 logger 未初始化时返回 zap.NewNop()
+func FromContext(ctx context.Context) *zap.Logger {
+	l := defaultLogger.Load()
+	if l == nil {
+		return zap.NewNop()
+	}
+	// 默认 logger 为包级函数跳过了一层调用栈，直接使用时需要还原
+	return l.WithOptions(zap.AddCallerSkip(-1)).With(ContextFields(ctx)...)
+}
+
+func DebugCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	defaultLogger.Load().Debug(msg, append(ContextFields(ctx), fields...)...)
+}
+
+func InfoCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	defaultLogger.Load().Info(msg, append(ContextFields(ctx), fields...)...)
+}
+
+func WarnCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	defaultLogger.Load().Warn(msg, append(ContextFields(ctx), fields...)...)
+}
+
+func ErrorCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	defaultLogger.Load().Error(msg, append(ContextFields(ctx), fields...)...)
+}
+
+func DPanicCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	defaultLogger.Load().DPanic(msg, append(ContextFields(ctx), fields...)...)
+}
+
+func PanicCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	defaultLogger.Load().Panic(msg, append(ContextFields(ctx), fields...)...)
+}
+
+func FatalCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	defaultLogger.Load().Fatal(msg, append(ContextFields(ctx), fields...)...)
+}
@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// useTestLogger 将默认 logger 替换为写入 buf 的 logger
+func useTestLogger(t *testing.T, enc zapcore.Encoder) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	old := defaultLogger.Load()
+	defaultLogger.Store(zap.New(zapcore.NewCore(enc, zapcore.AddSync(buf), zapcore.DebugLevel), zap.AddCaller(), zap.AddCallerSkip(1)))
+	t.Cleanup(func() { defaultLogger.Store(old) })
+	return buf
+}
+
+func decodeLine(t *testing.T, line string) map[string]interface{} {
+	t.Helper()
+	m := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		t.Fatalf("invalid json log %q: %v", line, err)
+	}
+	return m
+}
+
+func TestContextFields(t *testing.T) {
+	buf := useTestLogger(t, zapcore.NewJSONEncoder(GetEncoder()))
+
+	ctx := WithTraceID(context.Background(), "trace-1")
+	ctx = WithSpanID(ctx, "span-1")
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithContext(ctx, zap.String("user", "u1"))
+	ctx = WithContext(ctx, zap.Int("tenant", 7))
+
+	InfoCtx(ctx, "hello", zap.String("k", "v"))
+	m := decodeLine(t, buf.String())
+	for key, expected := range map[string]interface{}{
+		TraceIDKey: "trace-1", SpanIDKey: "span-1", RequestIDKey: "req-1",
+		"user": "u1", "tenant": float64(7), "k": "v", "msg": "hello",
+	} {
+		if m[key] != expected {
+			t.Errorf("%s expected %v, got %v", key, expected, m[key])
+		}
+	}
+	if caller, _ := m["caller"].(string); !strings.Contains(caller, "context_test.go") {
+		t.Errorf("InfoCtx caller expected context_test.go, got %v", m["caller"])
+	}
+
+	buf.Reset()
+	FromContext(ctx).Warn("from ctx")
+	m = decodeLine(t, buf.String())
+	if m[RequestIDKey] != "req-1" || m["user"] != "u1" {
+		t.Errorf("FromContext expected context fields, got %v", m)
+	}
+	if caller, _ := m["caller"].(string); !strings.Contains(caller, "context_test.go") {
+		t.Errorf("FromContext caller expected context_test.go, got %v", m["caller"])
+	}
+
+	// 父 context 不受子 context 附加字段影响
+	if len(ContextFields(WithContext(context.Background(), zap.String("a", "b")))) != 1 {
+		t.Errorf("ContextFields expected only own fields")
+	}
+}
+
+func TestTraceExtractor(t *testing.T) {
+	defer traceExtractor.Store(nil)
+	SetTraceExtractor(func(ctx context.Context) (string, string) {
+		return "otel-trace", "otel-span"
+	})
+
+	ctx := context.Background()
+	if TraceID(ctx) != "otel-trace" || SpanID(ctx) != "otel-span" {
+		t.Errorf("extractor expected otel ids, got %s %s", TraceID(ctx), SpanID(ctx))
+	}
+	// 显式设置的 ID 优先
+	if TraceID(WithTraceID(ctx, "explicit")) != "explicit" {
+		t.Errorf("explicit trace id expected to win")
+	}
+}
+
+func TestMisEncoderLogID(t *testing.T) {
+	buf := useTestLogger(t, NewMisEncoder(GetEncoder()))
+	logID := func() string {
+		line := buf.String()
+		buf.Reset()
+		// [时间] [业务名] [主机名] [级别] [logID] 日志体
+		parts := strings.SplitN(line, "] [", 5)
+		if len(parts) < 5 {
+			t.Fatalf("invalid mis line %q", line)
+		}
+		return parts[4][:strings.Index(parts[4], "]")]
+	}
+
+	Info("no id")
+	if id := logID(); id != "0" {
+		t.Errorf("logID expected 0 without ids, got %s", id)
+	}
+
+	InfoCtx(WithTraceID(context.Background(), "t-1"), "trace only")
+	if id := logID(); id != "t-1" {
+		t.Errorf("logID expected trace id, got %s", id)
+	}
+
+	ctx := WithRequestID(WithTraceID(context.Background(), "t-1"), "r-1")
+	InfoCtx(ctx, "request")
+	if id := logID(); id != "r-1" {
+		t.Errorf("logID expected request id, got %s", id)
+	}
+
+	FromContext(ctx).Info("with")
+	if id := logID(); id != "r-1" {
+		t.Errorf("logID expected request id from logger.With, got %s", id)
+	}
+
+	FromContext(WithTraceID(context.Background(), "t-2")).Info("override", zap.String(RequestIDKey, "r-2"))
+	if id := logID(); id != "r-2" {
+		t.Errorf("logID expected entry field to win, got %s", id)
+	}
+}
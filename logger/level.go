@@ -0,0 +1,230 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qkzsky/gutils/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// loggerLevel 单个 logger 的日志级别
+// 级别优先级：运行时设置（SetLevel / LevelHandler）> log.levels.<name> > log.level > app.mode
+type loggerLevel struct {
+	level zap.AtomicLevel
+	// override 运行时设置的级别，为空时使用配置
+	override *zapcore.Level
+	revertAt time.Time
+	timer    *time.Timer
+}
+
+var (
+	levelMu sync.Mutex
+	levels  = map[string]*loggerLevel{}
+)
+
+// atomicLevelFor 返回 logger 的日志级别，同名 logger 共享同一个 zap.AtomicLevel
+func atomicLevelFor(name string) zap.AtomicLevel {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	if l, ok := levels[name]; ok {
+		return l.level
+	}
+	l := &loggerLevel{level: zap.NewAtomicLevelAt(configuredLevel(name))}
+	levels[name] = l
+	return l.level
+}
+
+// configuredLevel 配置中 logger 的日志级别
+func configuredLevel(name string) zapcore.Level {
+	snap := config.Snapshot()
+	for _, path := range []string{"log.levels." + name, "log.level"} {
+		if s := snap.GetString(path); s != "" {
+			var l zapcore.Level
+			if err := l.Set(s); err == nil {
+				return l
+			}
+		}
+	}
+	return *GetLevel()
+}
+
+// refreshLevels 配置变更后重新计算未被运行时设置的 logger 级别
+func refreshLevels() {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	for name, l := range levels {
+		if l.override == nil {
+			l.level.SetLevel(configuredLevel(name))
+		}
+	}
+}
+
+// SetLevel 运行时设置日志级别，name 为空时设置全部 logger
+// revert > 0 时到期后恢复为配置中的级别，避免 debug 日志被遗忘开启
+func SetLevel(name string, level zapcore.Level, revert time.Duration) error {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	targets := map[string]*loggerLevel{}
+	if name == "" {
+		targets = levels
+	} else if l, ok := levels[name]; ok {
+		targets[name] = l
+	} else {
+		return fmt.Errorf("logger not found: %s", name)
+	}
+
+	for n, l := range targets {
+		if l.timer != nil {
+			l.timer.Stop()
+			l.timer, l.revertAt = nil, time.Time{}
+		}
+		lv := level
+		l.override = &lv
+		l.level.SetLevel(level)
+		if revert > 0 {
+			var t *time.Timer
+			t = time.AfterFunc(revert, func() {
+				levelMu.Lock()
+				defer levelMu.Unlock()
+				// 到期前已被重新设置时忽略
+				if l.timer == t {
+					l.reset(n)
+				}
+			})
+			l.revertAt, l.timer = time.Now().Add(revert), t
+		}
+	}
+	return nil
+}
+
+// ResetLevel 清除运行时设置，恢复为配置中的级别，name 为空时恢复全部 logger
+func ResetLevel(name string) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	for n, l := range levels {
+		if name == "" || n == name {
+			l.reset(n)
+		}
+	}
+}
+
+// reset 清除运行时设置，调用方需持有 levelMu
+func (l *loggerLevel) reset(name string) {
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	l.override, l.timer, l.revertAt = nil, nil, time.Time{}
+	l.level.SetLevel(configuredLevel(name))
+}
+
+// LevelInfo logger 当前的日志级别
+type LevelInfo struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+	// Override 是否为运行时设置
+	Override bool `json:"override"`
+	// RevertAt 运行时设置的到期时间
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// Levels 返回全部 logger 当前的日志级别
+func Levels() []LevelInfo {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	infos := make([]LevelInfo, 0, len(levels))
+	for name, l := range levels {
+		info := LevelInfo{Logger: name, Level: l.level.String(), Override: l.override != nil}
+		if !l.revertAt.IsZero() {
+			revertAt := l.revertAt
+			info.RevertAt = &revertAt
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Logger < infos[j].Logger })
+	return infos
+}
+
+type levelRequest struct {
+	Logger   string `json:"logger"`
+	Level    string `json:"level"`
+	Duration string `json:"duration"`
+}
+
+// LevelHandler 查看与修改日志级别的 http.Handler，pprof.HttpServer 已挂载在 <prefix>/log/level
+//
+//	GET    ?logger=name                                      查看级别，logger 为空时返回全部
+//	PUT    {"logger": "name", "level": "debug", "duration": "10m"} 设置级别，duration 到期后恢复
+//	DELETE ?logger=name                                      恢复为配置中的级别
+//
+// PUT 参数也可以通过 query 传递，如 PUT ?level=debug&duration=10m
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			req := levelRequest{Logger: query.Get("logger"), Level: query.Get("level"), Duration: query.Get("duration")}
+			if r.ContentLength != 0 && r.Body != nil {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					writeLevelError(w, http.StatusBadRequest, err)
+					return
+				}
+			}
+
+			var level zapcore.Level
+			if err := level.Set(req.Level); err != nil {
+				writeLevelError(w, http.StatusBadRequest, err)
+				return
+			}
+			var revert time.Duration
+			if req.Duration != "" {
+				d, err := time.ParseDuration(req.Duration)
+				if err != nil {
+					writeLevelError(w, http.StatusBadRequest, err)
+					return
+				}
+				revert = d
+			}
+			if err := SetLevel(req.Logger, level, revert); err != nil {
+				writeLevelError(w, http.StatusNotFound, err)
+				return
+			}
+		case http.MethodDelete:
+			ResetLevel(query.Get("logger"))
+		default:
+			writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+
+		infos := Levels()
+		if name := query.Get("logger"); name != "" {
+			filtered := infos[:0]
+			for _, info := range infos {
+				if info.Logger == name {
+					filtered = append(filtered, info)
+				}
+			}
+			if len(filtered) == 0 {
+				writeLevelError(w, http.StatusNotFound, fmt.Errorf("logger not found: %s", name))
+				return
+			}
+			infos = filtered
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(infos)
+	})
+}
+
+func writeLevelError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
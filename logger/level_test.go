@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/gutils/config"
+	"go.uber.org/zap/zapcore"
+)
+
+// useTestConfig 将默认配置替换为 content，并清空已创建的 logger 级别
+func useTestConfig(t *testing.T, content string) {
+	t.Helper()
+	c, err := config.LoadReader(strings.NewReader(content), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := config.Default()
+	config.SetDefaultConfig(c)
+
+	levelMu.Lock()
+	oldLevels := levels
+	levels = map[string]*loggerLevel{}
+	levelMu.Unlock()
+
+	t.Cleanup(func() {
+		ResetLevel("")
+		levelMu.Lock()
+		levels = oldLevels
+		levelMu.Unlock()
+		config.SetDefaultConfig(old)
+	})
+}
+
+func TestConfiguredLevel(t *testing.T) {
+	useTestConfig(t, `
+app:
+  mode: debug
+log:
+  level: warn
+  levels:
+    sql: error
+`)
+	if l := atomicLevelFor("app").Level(); l != zapcore.WarnLevel {
+		t.Errorf("log.level expected warn, got %s", l)
+	}
+	if l := atomicLevelFor("sql").Level(); l != zapcore.ErrorLevel {
+		t.Errorf("log.levels.sql expected error, got %s", l)
+	}
+
+	// 未配置 log.level 时使用 app.mode
+	c, err := config.LoadReader(strings.NewReader("app:\n  mode: debug\nlog:\n  levels:\n    sql: error\n"), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.SetDefaultConfig(c)
+	refreshLevels()
+	if l := atomicLevelFor("app").Level(); l != zapcore.DebugLevel {
+		t.Errorf("app.mode expected debug, got %s", l)
+	}
+	if l := atomicLevelFor("sql").Level(); l != zapcore.ErrorLevel {
+		t.Errorf("log.levels.sql expected error, got %s", l)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	useTestConfig(t, "log:\n  level: info\n")
+	app, sql := atomicLevelFor("app"), atomicLevelFor("sql")
+
+	if err := SetLevel("sql", zapcore.DebugLevel, 0); err != nil {
+		t.Fatal(err)
+	}
+	if app.Level() != zapcore.InfoLevel || sql.Level() != zapcore.DebugLevel {
+		t.Errorf("expected app=info sql=debug, got app=%s sql=%s", app.Level(), sql.Level())
+	}
+
+	// 运行时设置优先于配置变更
+	refreshLevels()
+	if sql.Level() != zapcore.DebugLevel {
+		t.Errorf("override expected to survive refresh, got %s", sql.Level())
+	}
+
+	if err := SetLevel("missing", zapcore.DebugLevel, 0); err == nil {
+		t.Error("expected error for unknown logger")
+	}
+
+	if err := SetLevel("", zapcore.ErrorLevel, 0); err != nil {
+		t.Fatal(err)
+	}
+	if app.Level() != zapcore.ErrorLevel || sql.Level() != zapcore.ErrorLevel {
+		t.Errorf("expected all error, got app=%s sql=%s", app.Level(), sql.Level())
+	}
+
+	ResetLevel("")
+	if app.Level() != zapcore.InfoLevel || sql.Level() != zapcore.InfoLevel {
+		t.Errorf("expected reset to info, got app=%s sql=%s", app.Level(), sql.Level())
+	}
+}
+
+func TestSetLevelRevert(t *testing.T) {
+	useTestConfig(t, "log:\n  level: info\n")
+	app := atomicLevelFor("app")
+
+	if err := SetLevel("app", zapcore.DebugLevel, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if infos := Levels(); len(infos) != 1 || infos[0].RevertAt == nil || !infos[0].Override {
+		t.Errorf("expected revert_at, got %+v", infos)
+	}
+	deadline := time.Now().Add(time.Second)
+	for app.Level() != zapcore.InfoLevel && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if app.Level() != zapcore.InfoLevel {
+		t.Fatalf("expected revert to info, got %s", app.Level())
+	}
+	if infos := Levels(); infos[0].Override || infos[0].RevertAt != nil {
+		t.Errorf("expected override cleared, got %+v", infos)
+	}
+
+	// 重新设置后旧的定时器不再生效
+	_ = SetLevel("app", zapcore.DebugLevel, 20*time.Millisecond)
+	_ = SetLevel("app", zapcore.WarnLevel, 0)
+	time.Sleep(50 * time.Millisecond)
+	if app.Level() != zapcore.WarnLevel {
+		t.Errorf("expected warn to stay, got %s", app.Level())
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	useTestConfig(t, "log:\n  level: info\n")
+	atomicLevelFor("app")
+	atomicLevelFor("sql")
+	h := LevelHandler()
+
+	do := func(method, target, body string) (int, []LevelInfo) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var infos []LevelInfo
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+				t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+			}
+		}
+		return rec.Code, infos
+	}
+
+	code, infos := do(http.MethodGet, "/", "")
+	if code != http.StatusOK || len(infos) != 2 || infos[0].Logger != "app" || infos[0].Level != "info" {
+		t.Errorf("GET expected app/sql at info, got %d %+v", code, infos)
+	}
+
+	code, infos = do(http.MethodPut, "/", `{"logger":"sql","level":"debug","duration":"10m"}`)
+	if code != http.StatusOK || len(infos) != 2 || infos[1].Level != "debug" || infos[1].RevertAt == nil {
+		t.Errorf("PUT expected sql at debug, got %d %+v", code, infos)
+	}
+
+	code, infos = do(http.MethodPut, "/?logger=app&level=warn", "")
+	if code != http.StatusOK || len(infos) != 1 || infos[0].Level != "warn" {
+		t.Errorf("PUT query expected app at warn, got %d %+v", code, infos)
+	}
+
+	code, infos = do(http.MethodDelete, "/?logger=sql", "")
+	if code != http.StatusOK || infos[0].Level != "info" || infos[0].Override {
+		t.Errorf("DELETE expected sql reset, got %d %+v", code, infos)
+	}
+
+	for _, tc := range []struct {
+		method, target, body string
+		code                 int
+	}{
+		{http.MethodPut, "/", `{"level":"verbose"}`, http.StatusBadRequest},
+		{http.MethodPut, "/", `{"level":"debug","duration":"soon"}`, http.StatusBadRequest},
+		{http.MethodPut, "/", `{"logger":"missing","level":"debug"}`, http.StatusNotFound},
+		{http.MethodGet, "/?logger=missing", "", http.StatusNotFound},
+		{http.MethodPatch, "/", "", http.StatusMethodNotAllowed},
+	} {
+		if code, _ := do(tc.method, tc.target, tc.body); code != tc.code {
+			t.Errorf("%s %s %s expected %d, got %d", tc.method, tc.target, tc.body, tc.code, code)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/gutils/config"
+	"go.uber.org/zap"
+)
+
+func TestInitLoggerReinit(t *testing.T) {
+	useTestLogPath(t)
+	useTestConfig(t, "log:\n  level: info\n")
+	old := defaultLogger.Load()
+	t.Cleanup(func() { defaultLogger.Store(old) })
+
+	first, second := t.TempDir(), t.TempDir()
+	InitLogger(first)
+	Info("to first")
+	InitLogger(second)
+	Info("to second")
+	if err := SyncAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	name := config.Name() + ".log"
+	a, _ := os.ReadFile(filepath.Join(first, name))
+	b, _ := os.ReadFile(filepath.Join(second, name))
+	if !strings.Contains(string(a), "to first") || strings.Contains(string(a), "to second") {
+		t.Errorf("unexpected first log %q", a)
+	}
+	if !strings.Contains(string(b), "to second") {
+		t.Errorf("unexpected second log %q", b)
+	}
+	// 默认 logger 的调用位置仍然跳过包级函数
+	if !strings.Contains(string(b), "lifecycle_test.go") {
+		t.Errorf("expected caller of package function, got %q", b)
+	}
+}
+
+func TestInitLoggerConcurrentWrites(t *testing.T) {
+	useTestLogPath(t)
+	useTestConfig(t, "log:\n  level: info\n")
+	old := defaultLogger.Load()
+	t.Cleanup(func() { defaultLogger.Store(old) })
+
+	InitLogger(t.TempDir())
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				Info("writing")
+				FromContext(context.Background()).Info("writing")
+			}
+		}
+	}()
+	// 重新初始化时其他 goroutine 仍在写默认 logger
+	for i := 0; i < 3; i++ {
+		InitLogger(t.TempDir())
+	}
+	close(stop)
+	<-done
+}
+
+func TestNewLoggerOptionsConflict(t *testing.T) {
+	dir := useTestLogPath(t)
+	useTestConfig(t, "log:\n  level: info\n")
+
+	l, err := NewLoggerE("conflict", zap.AddCaller())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := NewLoggerE("conflict", zap.AddCaller()); err != nil || again != l {
+		t.Errorf("expected same logger without error, got %v", err)
+	}
+	if again, err := NewLoggerE("conflict"); err != nil || again != l {
+		t.Errorf("expected same logger without options, got %v", err)
+	}
+	again, err := NewLoggerE("conflict", zap.Development())
+	if !errors.Is(err, ErrOptionsConflict) || again != l {
+		t.Errorf("expected ErrOptionsConflict with existing logger, got %v", err)
+	}
+
+	// NewLogger 输出警告并返回已有的 logger
+	if NewLogger("conflict", zap.Development()) != l {
+		t.Error("expected existing logger")
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "conflict.log"))
+	if !strings.Contains(string(content), ErrOptionsConflict.Error()) {
+		t.Errorf("expected conflict warning, got %q", content)
+	}
+
+	// 关闭后可以使用新的 options 重新创建
+	if err := Close("conflict"); err != nil {
+		t.Fatal(err)
+	}
+	if recreated, err := NewLoggerE("conflict", zap.Development()); err != nil || recreated == l {
+		t.Errorf("expected new logger after close, got %v", err)
+	}
+}
+
+func TestNewLoggerEConcurrent(t *testing.T) {
+	useTestLogPath(t)
+	useTestConfig(t, "log:\n  level: info\n")
+
+	loggers := make([]*zap.Logger, 8)
+	var wg sync.WaitGroup
+	for i := range loggers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loggers[i], _ = NewLoggerE("concurrent")
+		}()
+	}
+	wg.Wait()
+	// 同时创建时只保留一个 logger
+	for _, l := range loggers {
+		if l == nil || l != loggers[0] {
+			t.Fatal("expected the same logger")
+		}
+	}
+}
+
+func TestNewLoggerEInvalidConfig(t *testing.T) {
+	useTestLogPath(t)
+	useTestConfig(t, "log:\n  outputs:\n    - type: tcp\n")
+	if _, err := NewLoggerE("invalid"); err == nil {
+		t.Error("expected error for tcp output without address")
+	}
+	mu.RLock()
+	_, ok := loggerMap["invalid"]
+	mu.RUnlock()
+	if ok {
+		t.Error("expected failed logger not cached")
+	}
+}
+
+// blockingCloser 在 release 关闭前阻塞 Close
+type blockingCloser struct{ release chan struct{} }
+
+func (c blockingCloser) Close() error {
+	<-c.release
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	useTestLogPath(t)
+	useTestConfig(t, "log:\n  level: info\n")
+
+	NewLogger("shutdown").Info("bye")
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.RLock()
+	n := len(loggerMap)
+	mu.RUnlock()
+	if n != 0 {
+		t.Errorf("expected all loggers closed, %d left", n)
+	}
+
+	// 超时后不再等待
+	release := make(chan struct{})
+	defer close(release)
+	mu.Lock()
+	loggerMap["slow"] = zap.NewNop()
+	loggerClosers["slow"] = []io.Closer{blockingCloser{release: release}}
+	mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -1,31 +1,55 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/qkzsky/gutils/config"
 	"go.uber.org/zap/buffer"
+	"io"
+	"log"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var (
 	bufferPool = buffer.NewPool()
 	logPath    string
 	loggerMap  = map[string]*zap.Logger{}
-	mu         sync.RWMutex
-
-	defaultLogger  *zap.Logger
+	// loggerClosers 各 logger 的文件、网络连接等，Close 时关闭
+	loggerClosers = map[string][]io.Closer{}
+	// loggerOptions 各 logger 创建时 options 的签名，用于发现同名 logger 的 options 冲突
+	loggerOptions = map[string]string{}
+	mu            sync.RWMutex
+
+	// defaultLogger 默认 logger，InitLogger 重新初始化时整体替换
+	defaultLogger  atomic.Pointer[zap.Logger]
 	defaultMaxSize = 1 << 10 // 1GB
+
+	// watchOnce 日志级别随 app.mode、log.level、log.levels 变更实时调整，见 level.go
+	watchOnce sync.Once
 )
 
+func init() {
+	config.AddRule(
+		config.OneOf("log.level", "debug", "info", "warn", "error", "dpanic", "panic", "fatal"),
+		config.OneOf("log.levels.*", "debug", "info", "warn", "error", "dpanic", "panic", "fatal"),
+		config.OneOf("log.encode_type", "json", "mis"),
+		config.OneOf("log.stdout_encode", "json", "console", "none"),
+		config.Range("log.maxsize", 1, 1<<20),
+	)
+}
+
 func GetLevel() *zapcore.Level {
 	l := new(zapcore.Level)
-	mode := config.GetString("app.mode")
+	mode := config.Mode()
 	if err := l.Set(mode); err != nil {
 		_ = l.Set("info")
 	}
@@ -40,6 +64,8 @@ func GetEncoder() zapcore.EncoderConfig {
 	return encoder
 }
 
+// InitLogger 初始化日志目录与默认 logger，可以重复调用（如测试中切换目录）
+// 重复调用时先创建并切换到新的默认 logger，再关闭之前创建的全部 logger，之后的 NewLogger 按新的目录与配置创建
 func InitLogger(directory string, options ...zap.Option) {
 	var err error
 	if err = os.MkdirAll(directory, os.ModePerm); err != nil {
@@ -48,9 +74,26 @@ func InitLogger(directory string, options ...zap.Option) {
 		}
 	}
 
+	// 之前的 logger 从缓存中移除，但在新的默认 logger 生效前保持可写
+	mu.Lock()
+	previous := detach()
+	mu.Unlock()
 	logPath = directory
+	refreshLevels()
+	watchOnce.Do(func() {
+		for _, path := range []string{"app.mode", "log.level", "log.levels"} {
+			config.OnChange(path, func(_, _ interface{}) {
+				refreshLevels()
+			})
+		}
+	})
+
 	options = append(options, zap.AddCaller(), zap.AddCallerSkip(1))
-	defaultLogger = NewLogger(config.GetString("app.name"), options...)
+	defaultLogger.Store(NewLogger(config.Name(), options...))
+
+	if err := closeDetached(previous); err != nil {
+		log.Printf("[logger] close previous loggers: %s", err)
+	}
 }
 
 func GetLogPath() string {
@@ -58,112 +101,274 @@ func GetLogPath() string {
 }
 
 func GetDefaultLogger() *zap.Logger {
-	return defaultLogger
+	return defaultLogger.Load()
 }
 
 func TimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format("2006-01-02 15:04:05"))
 }
 
+// NewLogger 创建或返回同名的 logger，配置无效时 panic
+// 同名 logger 已存在且 options 不一致时输出警告并返回已有的 logger，见 NewLoggerE
 func NewLogger(logName string, options ...zap.Option) *zap.Logger {
+	logger, err := NewLoggerE(logName, options...)
+	if errors.Is(err, ErrOptionsConflict) {
+		logger.Warn(err.Error())
+		return logger
+	}
+	if err != nil {
+		panic(err)
+	}
+	return logger
+}
+
+// ErrOptionsConflict 同名 logger 已使用不同的 options 创建
+// zap 的 Option 为函数，无法比较参数，检查只能发现类型不同的 options，见 optionsSignature
+var ErrOptionsConflict = errors.New("options may conflict with the existing logger (compared by type only)")
+
+// NewLoggerE 创建或返回同名的 logger
+// 同名 logger 已存在且 options 不一致时返回已有的 logger 与 ErrOptionsConflict；
+// 检查是尽力而为的：options 按类型比较，参数不同（如 zap.AddCallerSkip(1) 与 zap.AddCallerSkip(2)）不会被发现
+// 未传 options 时总是返回已有的 logger
+func NewLoggerE(logName string, options ...zap.Option) (*zap.Logger, error) {
+	sig := optionsSignature(options)
 	mu.RLock()
-	logger, ok := loggerMap[logName]
+	logger, ok, err := existingLogger(logName, sig, len(options) > 0)
 	mu.RUnlock()
 	if ok {
-		return logger
+		return logger, err
+	}
+
+	// 创建输出可能需要连接网络，不持有 mu，避免阻塞其他 logger 的创建与写入
+	core, closers, err := newLoggerCore(logName)
+	if err != nil {
+		return nil, err
 	}
 
 	mu.Lock()
 	defer mu.Unlock()
+	// 其他 goroutine 已先创建同名 logger 时使用已有的
+	if logger, ok, err := existingLogger(logName, sig, len(options) > 0); ok {
+		_ = closeAll(closers)
+		return logger, err
+	}
+	logger = zap.New(core, options...)
+	loggerMap[logName] = logger
+	loggerClosers[logName] = closers
+	loggerOptions[logName] = sig
+	return logger, nil
+}
+
+// existingLogger 返回已创建的同名 logger，需持有 mu
+func existingLogger(logName, sig string, checkOptions bool) (*zap.Logger, bool, error) {
+	logger, ok := loggerMap[logName]
+	if !ok {
+		return nil, false, nil
+	}
+	if checkOptions && sig != loggerOptions[logName] {
+		return logger, true, fmt.Errorf("logger %s: %w", logName, ErrOptionsConflict)
+	}
+	return logger, true, nil
+}
 
-	fileName := fmt.Sprintf("%s/%s.log", logPath, logName)
-	logSection := config.GetStringMap("log")
-	fileWriters := []zapcore.WriteSyncer{zapcore.AddSync(&lumberjack.Logger{
-		Filename:  fileName,
-		MaxSize:   getIntFromMapWithDefault(logSection, "maxsize", defaultMaxSize), // MB
-		LocalTime: true,
-		Compress:  getBoolFromMapWithDefault(logSection, "compress", true),
-	})}
+// newLoggerCore 按配置创建 logger 的 core 以及需要在 Close 时关闭的输出
+func newLoggerCore(logName string) (zapcore.Core, []io.Closer, error) {
+	outputs, err := GetOutputs()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(outputs) == 0 {
+		return nil, nil, errors.New("logger: no output configured")
+	}
+	async, err := GetAsyncConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	redact, err := getRedactor()
+	if err != nil {
+		return nil, nil, err
+	}
+	otlp, err := GetOTLPConfig()
+	if err != nil {
+		return nil, nil, err
+	}
 
 	var (
-		logLevel = GetLevel()
-		encoder  = GetEncoder()
+		logLevel = atomicLevelFor(logName)
 		cores    []zapcore.Core
+		closers  []io.Closer
 	)
-
-	// 文件日志格式
-	switch getStringFromMap(logSection, "encode_type") {
-	case "mis":
-		cores = append(cores, zapcore.NewCore(NewMisEncoder(encoder), zap.CombineWriteSyncers(fileWriters...), logLevel))
-	case "json":
-		fallthrough
-	default:
-		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(encoder), zap.CombineWriteSyncers(fileWriters...), logLevel))
+	for _, o := range outputs {
+		core, closer, err := o.newCore(logName, logLevel, async, redact)
+		if err != nil {
+			_ = closeAll(closers)
+			return nil, nil, err
+		}
+		cores = append(cores, core)
+		if closer != nil {
+			closers = append(closers, closer)
+		}
 	}
-
-	// stdout 输出根据配置决定
-	stdoutEncode := getStringFromMap(logSection, "stdout_encode")
-	if stdoutEncode == "json" || stdoutEncode == "console" {
-		stdoutWriter, closeOut, err := zap.Open("stdout")
+	if otlp.Endpoint != "" {
+		core, closer, err := newOTLPCore(otlp, logName, logLevel, redact)
 		if err != nil {
-			if closeOut != nil {
-				closeOut()
-			}
-			panic(err)
+			_ = closeAll(closers)
+			return nil, nil, err
 		}
+		cores = append(cores, core)
+		closers = append(closers, closer)
+	}
+
+	core, rl, err := wrapCore(zapcore.NewTee(cores...))
+	if err != nil {
+		_ = closeAll(closers)
+		return nil, nil, err
+	}
+	if rl != nil {
+		// 先停止限流，使最后一次汇总写入尚未关闭的输出
+		closers = append([]io.Closer{rl}, closers...)
+	}
+	return core, closers, nil
+}
 
-		stdoutEncoder := GetEncoder()
-		if stdoutEncode == "console" {
-			stdoutEncoder.EncodeLevel = zapcore.CapitalColorLevelEncoder
-			cores = append(cores, zapcore.NewCore(zapcore.NewConsoleEncoder(stdoutEncoder), zap.CombineWriteSyncers(stdoutWriter), logLevel))
+// optionsSignature options 的类型签名
+// zap 的 Option 为函数，只能比较类型与函数地址；同一构造函数返回的闭包地址相同，参数不同也得到相同的签名
+func optionsSignature(options []zap.Option) string {
+	var b strings.Builder
+	for _, o := range options {
+		v := reflect.ValueOf(o)
+		if v.Kind() == reflect.Func {
+			fmt.Fprintf(&b, "%T@%x;", o, v.Pointer())
 		} else {
-			cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(stdoutEncoder), zap.CombineWriteSyncers(stdoutWriter), logLevel))
+			fmt.Fprintf(&b, "%#v;", o)
 		}
 	}
+	return b.String()
+}
 
-	if len(cores) == 0 {
-		panic("not found logger core")
+// Sync 等同于 SyncAll
+func Sync() error {
+	return SyncAll()
+}
+
+// SyncAll 将所有 logger 缓冲的日志写入输出，异步输出会等待队列中的日志全部写入
+func SyncAll() error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var errs []error
+	for name, l := range loggerMap {
+		if err := l.Sync(); err != nil {
+			errs = append(errs, fmt.Errorf("logger %s: %w", name, err))
+		}
 	}
+	return errors.Join(errs...)
+}
+
+// Close 写入缓冲的日志并关闭 logger 的文件与网络连接，names 为空时关闭全部 logger，通常在进程退出前调用
+// 关闭后 logger 从缓存中移除，再次调用 NewLogger 时重新创建；已关闭的 logger 仍可写入，但不再缓冲
+func Close(names ...string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	return closeDetached(detach(names...))
+}
 
-	if len(cores) > 1 {
-		logger = zap.New(zapcore.NewTee(cores...), options...)
-	} else {
-		logger = zap.New(cores[0], options...)
+// detachedLogger 已从缓存中移除、等待关闭的 logger
+type detachedLogger struct {
+	name    string
+	logger  *zap.Logger
+	closers []io.Closer
+}
+
+// detach 从缓存中移除 logger，names 为空时移除全部，需持有 mu
+func detach(names ...string) []detachedLogger {
+	if len(names) == 0 {
+		for name := range loggerMap {
+			names = append(names, name)
+		}
 	}
-	loggerMap[logName] = logger
-	return logger
+
+	var detached []detachedLogger
+	for _, name := range names {
+		l, ok := loggerMap[name]
+		if !ok {
+			continue
+		}
+		detached = append(detached, detachedLogger{name: name, logger: l, closers: loggerClosers[name]})
+		delete(loggerMap, name)
+		delete(loggerClosers, name)
+		delete(loggerOptions, name)
+	}
+	return detached
+}
+
+// closeDetached 写入缓冲的日志并关闭已移除的 logger
+func closeDetached(detached []detachedLogger) error {
+	var errs []error
+	for _, d := range detached {
+		// stdout/stderr 的 Sync 在部分系统上会返回错误，只以关闭结果为准
+		_ = d.logger.Sync()
+		if err := closeAll(d.closers); err != nil {
+			errs = append(errs, fmt.Errorf("logger %s: %w", d.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown 关闭全部 logger，ctx 结束时不再等待并返回 ctx.Err()，用于进程退出时限制等待时间
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	_ = logger.Shutdown(ctx)
+func Shutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() { done <- Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func closeAll(closers []io.Closer) error {
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
 }
 
 func Debug(msg string, fields ...zap.Field) {
-	defaultLogger.Debug(msg, fields...)
+	defaultLogger.Load().Debug(msg, fields...)
 }
 
 func Info(msg string, fields ...zap.Field) {
-	defaultLogger.Info(msg, fields...)
+	defaultLogger.Load().Info(msg, fields...)
 }
 
 func Warn(msg string, fields ...zap.Field) {
-	defaultLogger.Warn(msg, fields...)
+	defaultLogger.Load().Warn(msg, fields...)
 }
 
 func Error(msg string, fields ...zap.Field) {
-	defaultLogger.Error(msg, fields...)
+	defaultLogger.Load().Error(msg, fields...)
 }
 
 func DPanic(msg string, fields ...zap.Field) {
-	defaultLogger.DPanic(msg, fields...)
+	defaultLogger.Load().DPanic(msg, fields...)
 }
 
 func Panic(msg string, fields ...zap.Field) {
-	defaultLogger.Panic(msg, fields...)
+	defaultLogger.Load().Panic(msg, fields...)
 }
 
 func Fatal(msg string, fields ...zap.Field) {
-	defaultLogger.Fatal(msg, fields...)
+	defaultLogger.Load().Fatal(msg, fields...)
 }
 
 func Sugar() *zap.SugaredLogger {
-	return defaultLogger.Sugar()
+	return defaultLogger.Load().Sugar()
 }
 
 func getStringFromMap(m map[string]interface{}, key string) string {
@@ -21,54 +21,323 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qkzsky/gutils"
 	"github.com/qkzsky/gutils/config"
 	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/zapcore"
-	"os"
-	"time"
 )
 
+// DefaultMisFormat MIS 格式默认的前缀：[时间] [业务名] [主机名] [级别] [logID]
+// [2016-08-07 16:40:29] [my_service_name] [host-1] [INFO] [1306209370] {"level":"info",...}
+const DefaultMisFormat = "[{time}] [{app}] [{host}] [{level}] [{log_id}] "
+
+// MIS 格式的日志体
+const (
+	// MisBodyJSON 完整的 json 日志
+	MisBodyJSON = "json"
+	// MisBodyFields 去掉前缀中已有的时间、级别、logger 名称与调用位置的 json 日志
+	MisBodyFields = "fields"
+)
+
+// misPlaceholders 前缀模板支持的占位符，{time} 可以指定格式，如 {time:2006-01-02T15:04:05.000}
+var misPlaceholders = map[string]bool{
+	"time": true, "app": true, "host": true, "ip": true, "level": true, "log_id": true, "logger": true, "caller": true,
+}
+
+func init() {
+	config.AddRule(
+		config.OneOf("log.mis_body", MisBodyJSON, MisBodyFields),
+		func(c *config.Config) []config.Violation {
+			if _, err := parseMisTemplate(c.GetString("log.mis_format")); err != nil {
+				return []config.Violation{{Path: "log.mis_format", Message: err.Error()}}
+			}
+			return nil
+		},
+	)
+}
+
+type misSegment struct {
+	// name 占位符名称，为空时 text 为原样输出的文本
+	name string
+	text string
+	// layout {time} 的格式
+	layout string
+}
+
+// MisFormat 编译后的 MIS 行格式，用于输出与解析
+type MisFormat struct {
+	segments  []misSegment
+	logIDKeys []string
+	body      string
+}
+
+// NewMisFormat 编译 MIS 行格式，template 为空时使用 DefaultMisFormat
+// logIDKeys 为 logID 依次取值的字段，为空时为 request_id、trace_id，都没有值时为 0
+func NewMisFormat(template string, logIDKeys []string, body string) (*MisFormat, error) {
+	segments, err := parseMisTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(logIDKeys) == 0 {
+		logIDKeys = []string{RequestIDKey, TraceIDKey}
+	}
+	switch body {
+	case "":
+		body = MisBodyJSON
+	case MisBodyJSON, MisBodyFields:
+	default:
+		return nil, fmt.Errorf("logger: unknown mis body %q", body)
+	}
+	return &MisFormat{segments: segments, logIDKeys: logIDKeys, body: body}, nil
+}
+
+// GetMisFormat 返回 log.mis_format、log.mis_log_id、log.mis_body 配置的 MIS 行格式
+func GetMisFormat() (*MisFormat, error) {
+	return NewMisFormat(
+		config.GetString("log.mis_format"),
+		config.GetStringSlice("log.mis_log_id"),
+		config.GetString("log.mis_body"),
+	)
+}
+
+func parseMisTemplate(template string) ([]misSegment, error) {
+	if template == "" {
+		template = DefaultMisFormat
+	}
+
+	var segments []misSegment
+	for len(template) > 0 {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			segments = append(segments, misSegment{text: template})
+			break
+		}
+		if start > 0 {
+			segments = append(segments, misSegment{text: template[:start]})
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("logger: mis format %q: unclosed {", template)
+		}
+		name, layout, _ := strings.Cut(template[start+1:start+end], ":")
+		if !misPlaceholders[name] {
+			return nil, fmt.Errorf("logger: mis format: unknown placeholder {%s}", name)
+		}
+		if name == "time" && layout == "" {
+			layout = time.DateTime
+		}
+		if n := len(segments); n > 0 && segments[n-1].name != "" {
+			return nil, fmt.Errorf("logger: mis format: {%s} must be separated from {%s}", segments[n-1].name, name)
+		}
+		segments = append(segments, misSegment{name: name, layout: layout})
+		template = template[start+end+1:]
+	}
+	return segments, nil
+}
+
+// misHost 主机名与 IP 只在首次使用时获取
+var misHost = sync.OnceValues(func() (host, ip string) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	ip, _ = gutils.GetLocalIP()
+	return host, ip
+})
+
+// MisEncoder 按 MisFormat 输出前缀，之后为 json 日志体
+// logID 取 MisFormat 中第一个有值的字段，默认为 request_id、trace_id，都没有时为 0
 type MisEncoder struct {
 	zapcore.Encoder
+
+	format *MisFormat
+	app    string
+	// ids 通过 logger.With 附加的 logID 字段，与 format.logIDKeys 对应
+	ids []string
 }
 
+// NewMisEncoder 使用配置中的 MIS 行格式创建编码器，配置无效时使用默认格式
 func NewMisEncoder(cfg zapcore.EncoderConfig) *MisEncoder {
+	format, err := GetMisFormat()
+	if err != nil {
+		format, _ = NewMisFormat("", nil, "")
+	}
+	return NewMisEncoderWithFormat(cfg, format)
+}
+
+// NewMisEncoderWithFormat 使用指定的 MIS 行格式创建编码器
+func NewMisEncoderWithFormat(cfg zapcore.EncoderConfig, format *MisFormat) *MisEncoder {
+	if format.body == MisBodyFields {
+		cfg.TimeKey, cfg.LevelKey, cfg.NameKey, cfg.CallerKey, cfg.FunctionKey = "", "", "", "", ""
+	}
 	return &MisEncoder{
-		zapcore.NewJSONEncoder(cfg),
+		Encoder: zapcore.NewJSONEncoder(cfg),
+		format:  format,
+		app:     config.Name(),
+		ids:     make([]string, len(format.logIDKeys)),
 	}
 }
 
 func (t *MisEncoder) Clone() zapcore.Encoder {
-	return &MisEncoder{t.Encoder.Clone()}
+	return &MisEncoder{
+		Encoder: t.Encoder.Clone(),
+		format:  t.format,
+		app:     t.app,
+		ids:     append([]string(nil), t.ids...),
+	}
 }
 
-func (t *MisEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (buf *buffer.Buffer, err error) {
+// AddString 记录 logger.With 附加的 logID 字段
+func (t *MisEncoder) AddString(key, val string) {
+	for i, k := range t.format.logIDKeys {
+		if k == key {
+			t.ids[i] = val
+		}
+	}
+	t.Encoder.AddString(key, val)
+}
+
+// logID 本条日志的 logID，日志字段优先于 logger.With 附加的字段
+func (t *MisEncoder) logID(fields []zapcore.Field) string {
+	for i, key := range t.format.logIDKeys {
+		id := t.ids[i]
+		for _, f := range fields {
+			if f.Key == key && f.Type == zapcore.StringType {
+				id = f.String
+			}
+		}
+		if id != "" {
+			return id
+		}
+	}
+	return "0"
+}
+
+func (t *MisEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
 	line := bufferPool.Get()
+	for _, seg := range t.format.segments {
+		switch seg.name {
+		case "":
+			line.AppendString(seg.text)
+		case "time":
+			line.AppendTime(ent.Time, seg.layout)
+		case "app":
+			line.AppendString(t.app)
+		case "host":
+			host, _ := misHost()
+			line.AppendString(host)
+		case "ip":
+			_, ip := misHost()
+			line.AppendString(ip)
+		case "level":
+			line.AppendString(ent.Level.CapitalString())
+		case "log_id":
+			line.AppendString(t.logID(fields))
+		case "logger":
+			line.AppendString(ent.LoggerName)
+		case "caller":
+			if ent.Caller.Defined {
+				line.AppendString(ent.Caller.TrimmedPath())
+			}
+		}
+	}
 
-	// [时间] [业务名] [主机名] [级别] [logID] 日志体
-	// [2016-08-07 16:40:29] [my_service_name] [10.237.36.185] [NOTICE] [1306209370] {"appId":"my_service_name","clientIp":"127.0.0.1:24001","method":"HelloWorld","parent":977444679,"total":87}
-	var hostName string
-	hostName, err = os.Hostname()
+	body, err := t.Encoder.EncodeEntry(ent, fields)
 	if err != nil {
-		return
+		line.Free()
+		return nil, err
 	}
-	line.AppendString(fmt.Sprintf("[%s] [%s] [%s] [%s] [%s] ",
-		ent.Time.Format(time.DateTime),
-		config.AppName,
-		hostName,
-		ent.Level.CapitalString(),
-		"0",
-	))
+	_, _ = line.Write(body.Bytes())
+	body.Free()
+	return line, nil
+}
+
+// MisRecord 解析后的一行 MIS 日志，前缀中未配置的部分为零值
+type MisRecord struct {
+	Time   time.Time
+	App    string
+	Host   string
+	IP     string
+	Level  zapcore.Level
+	LogID  string
+	Logger string
+	Caller string
+	// Message 日志体中的 msg
+	Message string
+	// Fields 日志体的全部字段
+	Fields map[string]interface{}
+}
 
-	buf, err = t.Encoder.EncodeEntry(ent, fields)
+// ParseMisLine 按配置中的 MIS 行格式解析一行日志
+func ParseMisLine(line string) (*MisRecord, error) {
+	format, err := GetMisFormat()
 	if err != nil {
-		return
+		return nil, err
 	}
-	_, err = line.Write(buf.Bytes())
-	if err != nil {
-		return
+	return format.Parse(line)
+}
+
+// Parse 解析一行日志，占位符的值不能包含其后紧跟的文本
+func (f *MisFormat) Parse(line string) (*MisRecord, error) {
+	line = strings.TrimRight(line, "\r\n")
+	rec := &MisRecord{}
+	rest := line
+	for i, seg := range f.segments {
+		if seg.name == "" {
+			if !strings.HasPrefix(rest, seg.text) {
+				return nil, fmt.Errorf("logger: mis line: expected %q at %q", seg.text, rest)
+			}
+			rest = rest[len(seg.text):]
+			continue
+		}
+
+		// 占位符的值到下一段文本为止，最后一段时到日志体为止
+		end := strings.IndexByte(rest, '{')
+		if i+1 < len(f.segments) {
+			end = strings.Index(rest, f.segments[i+1].text)
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("logger: mis line: missing value of {%s}", seg.name)
+		}
+		val := rest[:end]
+		rest = rest[end:]
+
+		switch seg.name {
+		case "time":
+			t, err := time.ParseInLocation(seg.layout, val, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("logger: mis line: %w", err)
+			}
+			rec.Time = t
+		case "app":
+			rec.App = val
+		case "host":
+			rec.Host = val
+		case "ip":
+			rec.IP = val
+		case "level":
+			if err := rec.Level.UnmarshalText([]byte(val)); err != nil {
+				return nil, fmt.Errorf("logger: mis line: %w", err)
+			}
+		case "log_id":
+			rec.LogID = val
+		case "logger":
+			rec.Logger = val
+		case "caller":
+			rec.Caller = val
+		}
 	}
 
-	return line, nil
+	if err := json.Unmarshal([]byte(rest), &rec.Fields); err != nil {
+		return nil, fmt.Errorf("logger: mis line: body: %w", err)
+	}
+	rec.Message, _ = rec.Fields[GetEncoder().MessageKey].(string)
+	return rec, nil
 }
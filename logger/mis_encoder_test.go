@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/gutils/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMisFormatRoundTrip(t *testing.T) {
+	format, err := NewMisFormat("{time:2006-01-02T15:04:05.000} | {app} | {host} | {ip} | {level} | {log_id} | {logger} | {caller} | ", []string{"uid"}, MisBodyFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := NewMisEncoderWithFormat(GetEncoder(), format)
+	enc.AddString("uid", "u-1")
+
+	ent := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2024, 5, 6, 7, 8, 9, 123e6, time.Local),
+		LoggerName: "sql",
+		Message:    "slow query",
+		Caller:     zapcore.NewEntryCaller(0, "/src/gutils/database/logger.go", 74, true),
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Int("rows", 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := buf.String()
+	buf.Free()
+
+	rec, err := format.Parse(line)
+	if err != nil {
+		t.Fatalf("parse %q: %v", line, err)
+	}
+	host, ip := misHost()
+	if !rec.Time.Equal(ent.Time) || rec.App != config.Name() || rec.Host != host || rec.IP != ip ||
+		rec.Level != zapcore.WarnLevel || rec.LogID != "u-1" || rec.Logger != "sql" || rec.Caller != "database/logger.go:74" {
+		t.Errorf("unexpected record %+v from %q", rec, line)
+	}
+	if rec.Message != "slow query" || rec.Fields["rows"] != float64(3) || rec.Fields["uid"] != "u-1" {
+		t.Errorf("unexpected body %+v", rec.Fields)
+	}
+	// fields 日志体不重复前缀中的内容
+	for _, key := range []string{"level", "ts", "logger", "caller"} {
+		if _, ok := rec.Fields[key]; ok {
+			t.Errorf("fields body expected without %s, got %v", key, rec.Fields)
+		}
+	}
+}
+
+func TestParseMisLineDefault(t *testing.T) {
+	buf := useTestLogger(t, NewMisEncoder(GetEncoder()))
+	Info("hello", zap.String(RequestIDKey, "r-1"))
+
+	rec, err := ParseMisLine(buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, _ := misHost()
+	if rec.LogID != "r-1" || rec.Level != zapcore.InfoLevel || rec.Host != host || rec.Message != "hello" || rec.Time.IsZero() {
+		t.Errorf("unexpected record %+v", rec)
+	}
+
+	if _, err := ParseMisLine("not a mis line"); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestMisFormatInvalid(t *testing.T) {
+	for _, tmpl := range []string{"[{time}] [{unknown}]", "[{time", "{app}{host}"} {
+		if _, err := NewMisFormat(tmpl, nil, ""); err == nil {
+			t.Errorf("expected error for %q", tmpl)
+		}
+	}
+	if _, err := NewMisFormat("", nil, "xml"); err == nil {
+		t.Error("expected error for unknown body")
+	}
+
+	c, err := config.LoadReader(strings.NewReader("log:\n  mis_format: \"[{nope}] \"\n"), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "log.mis_format") {
+		t.Errorf("expected validation error for log.mis_format, got %v", err)
+	}
+}
+
+func TestMisEncoderAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("race detector allocates")
+	}
+	enc := NewMisEncoder(GetEncoder())
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "hello"}
+	fields := []zapcore.Field{zap.String(RequestIDKey, "r-1"), zap.Int("n", 1)}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, err := enc.EncodeEntry(ent, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Free()
+	})
+	if allocs > 0 {
+		t.Errorf("expected zero allocations, got %v", allocs)
+	}
+}
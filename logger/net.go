@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultNetTimeout 网络输出的连接与写入超时时间
+const DefaultNetTimeout = 3 * time.Second
+
+const (
+	// netPendingLines 连接断开期间缓存的日志行数，超过时丢弃最早的日志并计入 Dropped
+	netPendingLines = 1024
+	netMinBackoff   = 100 * time.Millisecond
+	netMaxBackoff   = 30 * time.Second
+)
+
+// netWriter 通过 TCP 或 UDP 输出日志，每条日志一行，供 Logstash、Vector 等日志采集器接收
+// 连接在后台建立，断开后按指数退避重连，期间日志缓存在内存中，连接后依次发送
+// 写日志不会等待建立连接，采集器不可用不影响启动与业务
+type netWriter struct {
+	mu      sync.Mutex
+	network string
+	address string
+	timeout time.Duration
+	conn    net.Conn
+	pending [][]byte
+	dialing bool
+	closed  bool
+	done    chan struct{}
+}
+
+func newNetWriter(network, address string) *netWriter {
+	w := &netWriter{network: network, address: address, timeout: DefaultNetTimeout, done: make(chan struct{})}
+	w.mu.Lock()
+	w.reconnect()
+	w.mu.Unlock()
+	return w
+}
+
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, errors.New("logger: network output closed")
+	}
+
+	if w.conn == nil || w.writeConn(p) != nil {
+		w.buffer(p)
+		w.reconnect()
+	}
+	return len(p), nil
+}
+
+// writeConn 写入当前连接，失败时关闭连接，需持有 mu
+func (w *netWriter) writeConn(p []byte) error {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	if _, err := w.conn.Write(p); err != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+// buffer 缓存未发送的日志，需持有 mu
+func (w *netWriter) buffer(p []byte) {
+	if len(w.pending) >= netPendingLines {
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+		dropped.Add(1)
+	}
+	w.pending = append(w.pending, append([]byte(nil), p...))
+}
+
+// reconnect 在后台建立连接，已在连接中时忽略，需持有 mu
+func (w *netWriter) reconnect() {
+	if w.dialing || w.closed {
+		return
+	}
+	w.dialing = true
+	go w.dial()
+}
+
+func (w *netWriter) dial() {
+	backoff := netMinBackoff
+	for {
+		conn, err := net.DialTimeout(w.network, w.address, w.timeout)
+
+		w.mu.Lock()
+		if w.closed {
+			w.dialing = false
+			w.mu.Unlock()
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		if err == nil {
+			w.conn, w.dialing = conn, false
+			w.flushPending()
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-time.After(backoff):
+		case <-w.done:
+		}
+		backoff = min(backoff*2, netMaxBackoff)
+	}
+}
+
+// flushPending 依次发送缓存的日志，连接再次断开时重新连接，需持有 mu
+func (w *netWriter) flushPending() {
+	for len(w.pending) > 0 {
+		if err := w.writeConn(w.pending[0]); err != nil {
+			w.reconnect()
+			return
+		}
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+	}
+	w.pending = nil
+}
+
+func (w *netWriter) Sync() error {
+	return nil
+}
+
+// Close 关闭连接，尚未发送的日志计入 Dropped
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	dropped.Add(uint64(len(w.pending)))
+	w.pending = nil
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,573 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qkzsky/gutils/config"
+	"go.uber.org/zap/zapcore"
+)
+
+// OTLPConfig log.otlp 配置，endpoint 不为空时每个 logger 额外通过 OTLP/HTTP（json 编码）导出日志，不支持 gRPC 与 protobuf 编码
+// 日志在后台批量发送，失败时按 retry_backoff 指数退避重试，仍然失败时写入 spool_dir，连接恢复后重新发送
+// Sync 与 Close 只发送一次，不重试
+type OTLPConfig struct {
+	// Endpoint collector 地址，如 http://otel-collector:4318，未指定路径时为 /v1/logs
+	Endpoint string            `yaml:"endpoint"`
+	Headers  map[string]string `yaml:"headers"`
+	// Level 导出的最低级别，与 logger 的级别同时生效
+	Level         string        `yaml:"level"`
+	Timeout       time.Duration `yaml:"timeout" default:"5s"`
+	BatchSize     int           `yaml:"batch_size" default:"512"`
+	QueueSize     int           `yaml:"queue_size" default:"8192"`
+	FlushInterval time.Duration `yaml:"flush_interval" default:"1s"`
+	MaxRetries    int           `yaml:"max_retries" default:"3"`
+	RetryBackoff  time.Duration `yaml:"retry_backoff" default:"500ms"`
+	// SpoolDir 发送失败的日志暂存目录，相对路径基于日志目录，为空时丢弃
+	SpoolDir      string `yaml:"spool_dir"`
+	SpoolMaxFiles int    `yaml:"spool_max_files" default:"1000"`
+}
+
+func init() {
+	config.AddRule(
+		config.OneOf("log.otlp.level", "debug", "info", "warn", "error", "dpanic", "panic", "fatal"),
+		config.Range("log.otlp.batch_size", 1, 1<<20),
+		config.Range("log.otlp.queue_size", 1, 1<<24),
+		config.Range("log.otlp.max_retries", 0, 100),
+	)
+}
+
+// GetOTLPConfig 返回 log.otlp 配置，Endpoint 为空时不导出
+func GetOTLPConfig() (OTLPConfig, error) {
+	var cfg OTLPConfig
+	err := config.UnmarshalKey("log.otlp", &cfg)
+	return cfg, err
+}
+
+// otlpScope 日志的 instrumentation scope，名称为 logger 名称
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string                 `json:"timeUnixNano"`
+	ObservedTimeUnixNano string                 `json:"observedTimeUnixNano"`
+	SeverityNumber       int                    `json:"severityNumber"`
+	SeverityText         string                 `json:"severityText"`
+	Body                 map[string]interface{} `json:"body"`
+	Attributes           []otlpKeyValue         `json:"attributes,omitempty"`
+	TraceID              string                 `json:"traceId,omitempty"`
+	SpanID               string                 `json:"spanId,omitempty"`
+}
+
+type otlpPayload struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+// otlpSeverity zap 级别对应的 OTLP severity number
+func otlpSeverity(l zapcore.Level) int {
+	switch l {
+	case zapcore.DebugLevel:
+		return 5
+	case zapcore.InfoLevel:
+		return 9
+	case zapcore.WarnLevel:
+		return 13
+	case zapcore.ErrorLevel:
+		return 17
+	case zapcore.DPanicLevel:
+		return 18
+	case zapcore.PanicLevel:
+		return 21
+	case zapcore.FatalLevel:
+		return 24
+	default:
+		return 0
+	}
+}
+
+// otlpResource 资源属性：应用名称、运行模式、主机名与 IP（设置 POD_IP 时为 k8s.pod.ip）
+func otlpResource() []otlpKeyValue {
+	host, ip := misHost()
+	ipKey := "host.ip"
+	if os.Getenv("POD_IP") != "" {
+		ipKey = "k8s.pod.ip"
+	}
+	return []otlpKeyValue{
+		{Key: "service.name", Value: otlpValue(config.Name())},
+		{Key: "deployment.environment", Value: otlpValue(config.Mode())},
+		{Key: "host.name", Value: otlpValue(host)},
+		{Key: ipKey, Value: otlpValue(ip)},
+	}
+}
+
+// otlpValue 转换为 OTLP json 的 AnyValue，int64 按规范编码为字符串
+func otlpValue(v interface{}) map[string]interface{} {
+	switch val := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": val}
+	case bool:
+		return map[string]interface{}{"boolValue": val}
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		return map[string]interface{}{"intValue": fmt.Sprint(val)}
+	case uint, uint64, uintptr:
+		u, _ := strconv.ParseUint(fmt.Sprint(val), 10, 64)
+		if u > math.MaxInt64 {
+			return map[string]interface{}{"stringValue": fmt.Sprint(val)}
+		}
+		return map[string]interface{}{"intValue": fmt.Sprint(val)}
+	case float32:
+		return map[string]interface{}{"doubleValue": float64(val)}
+	case float64:
+		return map[string]interface{}{"doubleValue": val}
+	case time.Time:
+		return map[string]interface{}{"stringValue": val.Format(time.RFC3339Nano)}
+	case time.Duration:
+		return map[string]interface{}{"stringValue": val.String()}
+	case []byte:
+		return map[string]interface{}{"bytesValue": val}
+	case []interface{}:
+		values := make([]map[string]interface{}, len(val))
+		for i, item := range val {
+			values[i] = otlpValue(item)
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case map[string]interface{}:
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": otlpAttributes(val)}}
+	case nil:
+		return map[string]interface{}{}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(val)}
+	}
+}
+
+func otlpAttributes(m map[string]interface{}) []otlpKeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpKeyValue{Key: k, Value: otlpValue(m[k])})
+	}
+	return attrs
+}
+
+// isHexID 判断是否为指定字节数的十六进制 ID，OTLP 的 traceId 为 16 字节，spanId 为 8 字节
+func isHexID(s string, size int) bool {
+	if len(s) != size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// otlpCore 将日志转换为 OTLP 日志记录交给 exporter 批量发送
+// 不读取 context 中的 span，只使用字段中的 trace_id、span_id（logger.InfoCtx、FromContext 等通过 SetTraceExtractor 附加，或显式写入）
+type otlpCore struct {
+	zapcore.LevelEnabler
+	exp    *otlpExporter
+	redact *redactor
+	fields []zapcore.Field
+}
+
+func (c *otlpCore) With(fields []zapcore.Field) zapcore.Core {
+	return &otlpCore{
+		LevelEnabler: c.LevelEnabler,
+		exp:          c.exp,
+		redact:       c.redact,
+		fields:       append(append([]zapcore.Field(nil), c.fields...), fields...),
+	}
+}
+
+func (c *otlpCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *otlpCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	var oe zapcore.ObjectEncoder = enc
+	if c.redact != nil {
+		oe = &redactObjectEncoder{ObjectEncoder: enc, r: c.redact}
+		ent.Message = c.redact.redactString(ent.Message)
+	}
+	for _, f := range c.fields {
+		f.AddTo(oe)
+	}
+	for _, f := range fields {
+		f.AddTo(oe)
+	}
+
+	rec := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(ent.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity(ent.Level),
+		SeverityText:         ent.Level.CapitalString(),
+		Body:                 otlpValue(ent.Message),
+	}
+	if id, ok := enc.Fields[TraceIDKey].(string); ok && isHexID(id, 16) {
+		rec.TraceID = id
+		delete(enc.Fields, TraceIDKey)
+	}
+	if id, ok := enc.Fields[SpanIDKey].(string); ok && isHexID(id, 8) {
+		rec.SpanID = id
+		delete(enc.Fields, SpanIDKey)
+	}
+	if ent.LoggerName != "" {
+		enc.Fields["logger.name"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		enc.Fields["code.filepath"] = ent.Caller.File
+		enc.Fields["code.lineno"] = ent.Caller.Line
+		if ent.Caller.Function != "" {
+			enc.Fields["code.function"] = ent.Caller.Function
+		}
+	}
+	if ent.Stack != "" {
+		enc.Fields["exception.stacktrace"] = ent.Stack
+	}
+	rec.Attributes = otlpAttributes(enc.Fields)
+
+	c.exp.enqueue(rec)
+	return nil
+}
+
+func (c *otlpCore) Sync() error {
+	return c.exp.flush()
+}
+
+// NewOTLPCore 创建导出到 OTLP collector 的 core，scope 为日志的 instrumentation scope 名称
+// 返回的 io.Closer 用于发送剩余日志并停止后台 goroutine
+func NewOTLPCore(cfg OTLPConfig, scope string, level zapcore.LevelEnabler) (zapcore.Core, io.Closer, error) {
+	return newOTLPCore(cfg, scope, level, nil)
+}
+
+func newOTLPCore(cfg OTLPConfig, scope string, level zapcore.LevelEnabler, redact *redactor) (zapcore.Core, io.Closer, error) {
+	exp, err := newOTLPExporter(cfg, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.Level != "" {
+		var min zapcore.Level
+		if err := min.Set(cfg.Level); err != nil {
+			_ = exp.Close()
+			return nil, nil, err
+		}
+		level = minLevel{LevelEnabler: level, min: min}
+	}
+	return &otlpCore{LevelEnabler: level, exp: exp, redact: redact}, exp, nil
+}
+
+// otlpExporter 批量发送日志记录，队列满时丢弃新日志并计入 Dropped
+type otlpExporter struct {
+	cfg      OTLPConfig
+	endpoint string
+	client   *http.Client
+	resource []otlpKeyValue
+	scope    string
+	spoolDir string
+
+	queue   chan otlpLogRecord
+	flushCh chan chan error
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newOTLPExporter(cfg OTLPConfig, scope string) (*otlpExporter, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("logger: invalid otlp endpoint %q", cfg.Endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/logs"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 8192
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+
+	e := &otlpExporter{
+		cfg:      cfg,
+		endpoint: u.String(),
+		client:   &http.Client{Timeout: cfg.Timeout},
+		resource: otlpResource(),
+		scope:    scope,
+		queue:    make(chan otlpLogRecord, cfg.QueueSize),
+		flushCh:  make(chan chan error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if cfg.SpoolDir != "" {
+		e.spoolDir = cfg.SpoolDir
+		if !filepath.IsAbs(e.spoolDir) {
+			e.spoolDir = filepath.Join(logPath, e.spoolDir)
+		}
+		// 每个 logger 单独的目录，避免多个 exporter 重复发送同一个文件
+		e.spoolDir = filepath.Join(e.spoolDir, scope)
+		if err := os.MkdirAll(e.spoolDir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	go e.run()
+	return e, nil
+}
+
+func (e *otlpExporter) enqueue(rec otlpLogRecord) {
+	select {
+	case e.queue <- rec:
+	default:
+		dropped.Add(1)
+	}
+}
+
+func (e *otlpExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpLogRecord, 0, e.cfg.BatchSize)
+	// retry 为 false 时只发送一次，用于 Sync 与 Close，不等待重试
+	send := func(retry bool) error {
+		if len(batch) == 0 {
+			return e.replaySpool()
+		}
+		err := e.export(batch, retry)
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		select {
+		case rec := <-e.queue:
+			batch = append(batch, rec)
+			if len(batch) >= e.cfg.BatchSize {
+				_ = send(true)
+			}
+		case <-ticker.C:
+			_ = send(true)
+		case ch := <-e.flushCh:
+			for n := len(e.queue); n > 0; n-- {
+				batch = append(batch, <-e.queue)
+			}
+			ch <- send(false)
+		case <-e.stop:
+			for n := len(e.queue); n > 0; n-- {
+				batch = append(batch, <-e.queue)
+			}
+			_ = send(false)
+			return
+		}
+	}
+}
+
+// flush 发送队列中的日志，返回发送结果
+// 只发送一次，失败时写入 spool；后台正在重试时最多等待 Timeout，超时返回 errFlushTimeout，日志留在队列中稍后发送
+func (e *otlpExporter) flush() error {
+	wait := e.client.Timeout
+	if wait <= 0 {
+		wait = defaultOTLPFlushWait
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	ch := make(chan error, 1)
+	select {
+	case e.flushCh <- ch:
+		return <-ch
+	case <-e.done:
+		return nil
+	case <-timer.C:
+		return errFlushTimeout
+	}
+}
+
+// defaultOTLPFlushWait 未设置 Timeout 时 Sync 等待后台发送的最长时间
+const defaultOTLPFlushWait = 5 * time.Second
+
+// errFlushTimeout 后台正在重试发送，Sync 不再等待
+var errFlushTimeout = errors.New("logger: otlp: flush timeout, exporter is busy retrying")
+
+// export 发送一批日志，retry 为 true 时失败后按 retry_backoff 重试
+// 仍然失败时写入 spool，collector 拒绝的日志直接丢弃
+func (e *otlpExporter) export(batch []otlpLogRecord, retry bool) error {
+	payload := otlpPayload{ResourceLogs: []otlpResourceLogs{{ScopeLogs: []otlpScopeLogs{{
+		Scope:      otlpScope{Name: e.scope},
+		LogRecords: batch,
+	}}}}}
+	payload.ResourceLogs[0].Resource.Attributes = e.resource
+	body, err := json.Marshal(payload)
+	if err != nil {
+		dropped.Add(uint64(len(batch)))
+		return err
+	}
+
+	// 先补发 spool 中较早的日志，保持发送顺序
+	err = e.replaySpool()
+	switch {
+	case err != nil && !retry:
+		// collector 仍不可用，不再发送，避免 Sync 等待两次超时
+	case retry:
+		err = e.post(body)
+	default:
+		err = e.postOnce(body)
+	}
+	if err != nil {
+		if errors.Is(err, errPermanent) {
+			dropped.Add(uint64(len(batch)))
+			return err
+		}
+		if spoolErr := e.spool(body); spoolErr != nil {
+			dropped.Add(uint64(len(batch)))
+			return errors.Join(err, spoolErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// errPermanent collector 拒绝的请求，重试也不会成功
+var errPermanent = errors.New("permanent error")
+
+// post 发送请求，网络错误、429 与 5xx 时指数退避重试
+func (e *otlpExporter) post(body []byte) error {
+	backoff := e.cfg.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= e.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-e.stop:
+				// 关闭时不再等待重试，交给 spool
+				return err
+			}
+			backoff *= 2
+		}
+		if err = e.postOnce(body); err == nil || errors.Is(err, errPermanent) {
+			return err
+		}
+	}
+	return err
+}
+
+func (e *otlpExporter) postOnce(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.client.Timeout)
+	if e.client.Timeout == 0 {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("logger: otlp: %s", resp.Status)
+	default:
+		return fmt.Errorf("logger: otlp: %s: %w", resp.Status, errPermanent)
+	}
+}
+
+// spool 将发送失败的请求写入 spool 目录，超过 SpoolMaxFiles 时删除最早的文件
+func (e *otlpExporter) spool(body []byte) error {
+	if e.spoolDir == "" {
+		return errors.New("logger: otlp: spool disabled")
+	}
+	name := filepath.Join(e.spoolDir, fmt.Sprintf("%020d.json", time.Now().UnixNano()))
+	if err := os.WriteFile(name, body, 0o600); err != nil {
+		return err
+	}
+
+	files := e.spoolFiles()
+	if max := e.cfg.SpoolMaxFiles; max > 0 && len(files) > max {
+		for _, f := range files[:len(files)-max] {
+			_ = os.Remove(f)
+		}
+	}
+	return nil
+}
+
+func (e *otlpExporter) spoolFiles() []string {
+	if e.spoolDir == "" {
+		return nil
+	}
+	files, _ := filepath.Glob(filepath.Join(e.spoolDir, "*.json"))
+	sort.Strings(files)
+	return files
+}
+
+// replaySpool 按时间顺序补发 spool 中的请求，遇到失败时停止
+func (e *otlpExporter) replaySpool() error {
+	for _, f := range e.spoolFiles() {
+		body, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if err := e.postOnce(body); err != nil && !errors.Is(err, errPermanent) {
+			return err
+		}
+		_ = os.Remove(f)
+	}
+	return nil
+}
+
+// Close 发送剩余日志并停止后台 goroutine，发送失败的日志写入 spool
+func (e *otlpExporter) Close() error {
+	e.once.Do(func() { close(e.stop) })
+	<-e.done
+	return nil
+}
@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// testCollector 进程内的 OTLP/HTTP collector，status 不为 0 时返回该状态码
+type testCollector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests int
+	records  []map[string]interface{}
+	resource map[string]interface{}
+}
+
+func newTestCollector(t *testing.T) *testCollector {
+	t.Helper()
+	c := &testCollector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.requests++
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if c.status != 0 {
+			w.WriteHeader(c.status)
+			return
+		}
+		var payload otlpPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, rl := range payload.ResourceLogs {
+			c.resource = map[string]interface{}{}
+			for _, kv := range rl.Resource.Attributes {
+				c.resource[kv.Key] = kv.Value["stringValue"]
+			}
+			for _, sl := range rl.ScopeLogs {
+				for _, rec := range sl.LogRecords {
+					m := map[string]interface{}{
+						"scope":    sl.Scope.Name,
+						"body":     rec.Body["stringValue"],
+						"severity": rec.SeverityNumber,
+						"traceId":  rec.TraceID,
+						"spanId":   rec.SpanID,
+					}
+					for _, kv := range rec.Attributes {
+						for _, v := range kv.Value {
+							m[kv.Key] = v
+						}
+					}
+					c.records = append(c.records, m)
+				}
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *testCollector) setStatus(status int) {
+	c.mu.Lock()
+	c.status = status
+	c.mu.Unlock()
+}
+
+func (c *testCollector) received() ([]map[string]interface{}, map[string]interface{}, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]map[string]interface{}(nil), c.records...), c.resource, c.requests
+}
+
+func TestOTLPExport(t *testing.T) {
+	useTestLogPath(t)
+	collector := newTestCollector(t)
+	useTestConfig(t, fmt.Sprintf(`
+app:
+  name: otlp-app
+  mode: release
+log:
+  level: info
+  redact:
+    - fields: [password]
+  otlp:
+    endpoint: %s
+    flush_interval: 1h
+`, collector.URL))
+
+	traceID, spanID := "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	ctx := WithSpanID(WithTraceID(context.Background(), traceID), spanID)
+	l := NewLogger("otlp")
+	l.Debug("filtered")
+	l.With(ContextFields(ctx)...).Warn("slow request", zap.Int("cost", 120), zap.String("password", "p1"))
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, resource, _ := collector.received()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %v", records)
+	}
+	rec := records[0]
+	if rec["scope"] != "otlp" || rec["body"] != "slow request" || rec["severity"] != 13 ||
+		rec["traceId"] != traceID || rec["spanId"] != spanID {
+		t.Errorf("unexpected record %v", rec)
+	}
+	if rec["cost"] != "120" || rec["password"] != redactMaskValue || rec[TraceIDKey] != nil {
+		t.Errorf("unexpected attributes %v", rec)
+	}
+	host, _ := misHost()
+	if resource["service.name"] != "otlp-app" || resource["deployment.environment"] != "release" || resource["host.name"] != host {
+		t.Errorf("unexpected resource %v", resource)
+	}
+}
+
+func TestOTLPRetryAndSpool(t *testing.T) {
+	dir := useTestLogPath(t)
+	collector := newTestCollector(t)
+	collector.setStatus(http.StatusServiceUnavailable)
+
+	core, closer, err := NewOTLPCore(OTLPConfig{
+		Endpoint:      collector.URL,
+		BatchSize:     10,
+		QueueSize:     100,
+		FlushInterval: 1 << 40,
+		MaxRetries:    2,
+		RetryBackoff:  1,
+		SpoolDir:      "spool",
+		SpoolMaxFiles: 2,
+	}, "spooled", zap.InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	l := zap.New(core)
+
+	// collector 不可用时 Sync 只发送一次，失败后写入 spool，超过 SpoolMaxFiles 时删除最早的文件
+	for i := 0; i < 3; i++ {
+		l.Info(fmt.Sprintf("msg %d", i))
+		if err := l.Sync(); err == nil {
+			t.Fatal("expected export error")
+		}
+	}
+	_, _, requests := collector.received()
+	if requests != 3 {
+		t.Errorf("expected 1 attempt per Sync, got %d requests", requests)
+	}
+	spooled, _ := filepath.Glob(filepath.Join(dir, "spool", "spooled", "*.json"))
+	if len(spooled) != 2 {
+		t.Fatalf("expected 2 spooled files, got %v", spooled)
+	}
+
+	// collector 恢复后按顺序补发
+	collector.setStatus(0)
+	l.Info("msg 3")
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	records, _, _ := collector.received()
+	var bodies []interface{}
+	for _, rec := range records {
+		bodies = append(bodies, rec["body"])
+	}
+	if fmt.Sprint(bodies) != "[msg 1 msg 2 msg 3]" {
+		t.Errorf("unexpected records %v", bodies)
+	}
+	if spooled, _ := filepath.Glob(filepath.Join(dir, "spool", "spooled", "*.json")); len(spooled) != 0 {
+		t.Errorf("expected spool drained, got %v", spooled)
+	}
+}
+
+func TestOTLPBackgroundRetry(t *testing.T) {
+	dir := useTestLogPath(t)
+	collector := newTestCollector(t)
+	collector.setStatus(http.StatusServiceUnavailable)
+
+	core, closer, err := NewOTLPCore(OTLPConfig{
+		Endpoint:      collector.URL,
+		Timeout:       50 * time.Millisecond,
+		BatchSize:     1,
+		FlushInterval: 1 << 40,
+		MaxRetries:    2,
+		RetryBackoff:  1,
+		SpoolDir:      "spool",
+	}, "retry", zap.InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := zap.New(core)
+
+	// 后台发送按 max_retries 重试后写入 spool
+	l.Info("msg")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		spooled, _ := filepath.Glob(filepath.Join(dir, "spool", "retry", "*.json"))
+		if len(spooled) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected batch spooled after retries")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, _, requests := collector.received(); requests != 3 {
+		t.Errorf("expected 3 attempts, got %d requests", requests)
+	}
+
+	_ = closer.Close()
+}
+
+func TestOTLPSyncDuringRetry(t *testing.T) {
+	dir := useTestLogPath(t)
+	collector := newTestCollector(t)
+	collector.setStatus(http.StatusServiceUnavailable)
+
+	core, closer, err := NewOTLPCore(OTLPConfig{
+		Endpoint:      collector.URL,
+		Timeout:       50 * time.Millisecond,
+		BatchSize:     1,
+		FlushInterval: 1 << 40,
+		MaxRetries:    1,
+		RetryBackoff:  time.Hour,
+		SpoolDir:      "spool",
+	}, "busy", zap.InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := zap.New(core)
+
+	// 后台等待重试时 Sync 最多等待 Timeout，不等待整个重试周期
+	l.Info("msg")
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if _, _, requests := collector.received(); requests == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected background export")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	start := time.Now()
+	if err := l.Sync(); !errors.Is(err, errFlushTimeout) {
+		t.Errorf("expected flush timeout, got %v", err)
+	}
+	if cost := time.Since(start); cost > time.Second {
+		t.Errorf("Sync blocked for %s", cost)
+	}
+
+	// Close 不等待重试，写入 spool
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if spooled, _ := filepath.Glob(filepath.Join(dir, "spool", "busy", "*.json")); len(spooled) != 1 {
+		t.Errorf("expected batch spooled on close, got %v", spooled)
+	}
+}
+
+func TestOTLPPermanentError(t *testing.T) {
+	dir := useTestLogPath(t)
+	collector := newTestCollector(t)
+	collector.setStatus(http.StatusBadRequest)
+
+	core, closer, err := NewOTLPCore(OTLPConfig{Endpoint: collector.URL, FlushInterval: 1 << 40, MaxRetries: 3, SpoolDir: dir}, "bad", zap.InfoLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := zap.New(core)
+	l.Info("rejected")
+	_ = l.Sync()
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, requests := collector.received(); requests != 1 {
+		t.Errorf("expected no retry for 4xx, got %d requests", requests)
+	}
+
+	if _, _, err := NewOTLPCore(OTLPConfig{Endpoint: "collector:4318"}, "bad", zap.InfoLevel); err == nil {
+		t.Error("expected invalid endpoint error")
+	}
+	if spooled, _ := filepath.Glob(filepath.Join(dir, "bad", "*.json")); len(spooled) != 0 {
+		t.Errorf("expected rejected logs not spooled, got %v", spooled)
+	}
+}
@@ -0,0 +1,245 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/qkzsky/gutils/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// 输出类型
+const (
+	OutputFile   = "file"
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputSyslog = "syslog"
+	OutputTCP    = "tcp"
+	OutputUDP    = "udp"
+)
+
+// 文件切割方式
+const (
+	RotateSize   = "size"
+	RotateDaily  = "daily"
+	RotateHourly = "hourly"
+)
+
+// Output log.outputs 中的一个日志输出
+// 未配置 log.outputs 时使用 log.encode_type、log.maxsize 等旧配置生成文件输出与 stdout 输出
+type Output struct {
+	// Type 输出类型：file/stdout/stderr/syslog/tcp/udp
+	Type string `yaml:"type" default:"file"`
+	// EncodeType 日志格式：json/mis/console，文件输出默认为 log.encode_type，stdout/stderr 默认为 console，tcp/udp 默认为 json
+	EncodeType string `yaml:"encode_type"`
+	// Level 输出的最低级别，与 logger 的级别同时生效
+	Level string `yaml:"level"`
+	// Async 是否异步输出，默认为 log.async.enabled
+	Async *bool `yaml:"async"`
+
+	// Filename 文件路径，{name} 替换为 logger 名称，相对路径基于日志目录，默认为 {name}.log
+	Filename string `yaml:"filename"`
+	// Rotate 切割方式：size 按大小，daily 按天，hourly 按小时
+	Rotate string `yaml:"rotate" default:"size"`
+	// MaxSize 按大小切割时单个文件的大小，单位 MB，默认为 log.maxsize
+	MaxSize int `yaml:"maxsize"`
+	// MaxBackups 保留的历史文件数，0 为不限制，未配置时为 log.max_backups
+	MaxBackups *int `yaml:"max_backups"`
+	// MaxAge 历史文件保留天数，0 为不限制，未配置时为 log.max_age
+	MaxAge *int `yaml:"max_age"`
+	// Compress 按大小切割时是否压缩历史文件，默认为 log.compress
+	Compress *bool `yaml:"compress"`
+
+	// Network syslog 的连接方式：udp/tcp/unix，为空时连接本机 syslog
+	Network string `yaml:"network"`
+	// Address syslog、tcp、udp 的地址，如 127.0.0.1:514、/dev/log
+	Address string `yaml:"address"`
+	// Tag syslog 的 tag，默认为 logger 名称
+	Tag string `yaml:"tag"`
+	// Facility syslog 的 facility，如 local0、daemon
+	Facility string `yaml:"facility" default:"local0"`
+}
+
+func init() {
+	config.AddRule(
+		config.OneOf("log.outputs.*.type", OutputFile, OutputStdout, OutputStderr, OutputSyslog, OutputTCP, OutputUDP),
+		config.OneOf("log.outputs.*.encode_type", "json", "mis", "console"),
+		config.OneOf("log.outputs.*.level", "debug", "info", "warn", "error", "dpanic", "panic", "fatal"),
+		config.OneOf("log.outputs.*.rotate", RotateSize, RotateDaily, RotateHourly),
+		config.Range("log.outputs.*.maxsize", 1, 1<<20),
+		config.Range("log.max_backups", 0, 1<<20),
+		config.Range("log.async.buffer_size", 1, 1<<24),
+		config.OneOf("log.async.overflow", OverflowBlock, OverflowDropNewest, OverflowDropOldest),
+		config.Range("log.max_age", 0, 1<<20),
+	)
+}
+
+// GetOutputs 返回 log.outputs 配置的日志输出，未配置时按旧配置生成
+func GetOutputs() ([]Output, error) {
+	logSection := config.GetStringMap("log")
+	compress := getBoolFromMapWithDefault(logSection, "compress", true)
+
+	var outputs []Output
+	if config.IsSet("log.outputs") {
+		if err := config.UnmarshalKey("log.outputs", &outputs); err != nil {
+			return nil, err
+		}
+	} else {
+		outputs = append(outputs, Output{Type: OutputFile, Rotate: RotateSize})
+		if stdoutEncode := getStringFromMap(logSection, "stdout_encode"); stdoutEncode == "json" || stdoutEncode == "console" {
+			outputs = append(outputs, Output{Type: OutputStdout, EncodeType: stdoutEncode})
+		}
+	}
+
+	for i := range outputs {
+		o := &outputs[i]
+		if o.EncodeType == "" {
+			switch o.Type {
+			case OutputStdout, OutputStderr:
+				o.EncodeType = "console"
+			case OutputTCP, OutputUDP:
+				// 日志采集器通常按 json 行解析
+				o.EncodeType = "json"
+			default:
+				o.EncodeType = getStringFromMap(logSection, "encode_type")
+			}
+		}
+		if o.Filename == "" {
+			o.Filename = "{name}.log"
+		}
+		if o.MaxSize == 0 {
+			o.MaxSize = getIntFromMapWithDefault(logSection, "maxsize", defaultMaxSize)
+		}
+		if o.MaxBackups == nil {
+			n := getIntFromMapWithDefault(logSection, "max_backups", 0)
+			o.MaxBackups = &n
+		}
+		if o.MaxAge == nil {
+			n := getIntFromMapWithDefault(logSection, "max_age", 0)
+			o.MaxAge = &n
+		}
+		if o.Compress == nil {
+			o.Compress = &compress
+		}
+	}
+	return outputs, nil
+}
+
+// GetAsyncConfig 返回 log.async 异步输出配置
+func GetAsyncConfig() (AsyncConfig, error) {
+	var cfg AsyncConfig
+	err := config.UnmarshalKey("log.async", &cfg)
+	return cfg, err
+}
+
+// newCore 创建输出对应的 zapcore.Core，level 为 logger 的日志级别，redact 不为空时脱敏
+// closer 用于关闭文件、网络连接等，stdout/stderr 时为 nil
+func (o Output) newCore(logName string, level zapcore.LevelEnabler, async AsyncConfig, redact *redactor) (zapcore.Core, io.Closer, error) {
+	if o.Level != "" {
+		var min zapcore.Level
+		if err := min.Set(o.Level); err != nil {
+			return nil, nil, err
+		}
+		level = minLevel{LevelEnabler: level, min: min}
+	}
+
+	if o.Type == OutputSyslog {
+		core, err := newSyslogCore(o, logName, o.encoder(redact), level)
+		if err != nil {
+			return nil, nil, err
+		}
+		closer, _ := core.(io.Closer)
+		return core, closer, nil
+	}
+
+	ws, closer, err := o.writer(logName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.Async != nil {
+		async.Enabled = *o.Async
+	}
+	if async.Enabled {
+		aw := newAsyncWriter(ws, closer, async)
+		ws, closer = aw, aw
+	}
+	return zapcore.NewCore(o.encoder(redact), ws, level), closer, nil
+}
+
+func (o Output) encoder(redact *redactor) zapcore.Encoder {
+	cfg := GetEncoder()
+	var enc zapcore.Encoder
+	switch o.EncodeType {
+	case "mis":
+		enc = NewMisEncoder(cfg)
+	case "console":
+		if o.Type == OutputStdout || o.Type == OutputStderr {
+			cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		enc = zapcore.NewConsoleEncoder(cfg)
+	default:
+		enc = zapcore.NewJSONEncoder(cfg)
+	}
+	if redact != nil {
+		enc = newRedactEncoder(enc, redact)
+	}
+	return enc
+}
+
+func (o Output) writer(logName string) (zapcore.WriteSyncer, io.Closer, error) {
+	switch o.Type {
+	case OutputStdout, OutputStderr:
+		ws, _, err := zap.Open(o.Type)
+		return ws, nil, err
+	case OutputTCP, OutputUDP:
+		if o.Address == "" {
+			return nil, nil, fmt.Errorf("logger: %s output requires address", o.Type)
+		}
+		w := newNetWriter(o.Type, o.Address)
+		return w, w, nil
+	case OutputFile, "":
+		filename := strings.ReplaceAll(o.Filename, "{name}", logName)
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(logPath, filename)
+		}
+		switch o.Rotate {
+		case RotateDaily, RotateHourly:
+			w := newTimeRotateWriter(filename, o.Rotate, intValue(o.MaxBackups), intValue(o.MaxAge))
+			return w, w, nil
+		default:
+			w := &lumberjack.Logger{
+				Filename:   filename,
+				MaxSize:    o.MaxSize, // MB
+				MaxBackups: intValue(o.MaxBackups),
+				MaxAge:     intValue(o.MaxAge),
+				LocalTime:  true,
+				Compress:   o.Compress != nil && *o.Compress,
+			}
+			return zapcore.AddSync(w), w, nil
+		}
+	default:
+		return nil, nil, fmt.Errorf("logger: unknown output type %q", o.Type)
+	}
+}
+
+// minLevel 输出级别与 logger 级别同时满足时才输出
+type minLevel struct {
+	zapcore.LevelEnabler
+	min zapcore.Level
+}
+
+func (l minLevel) Enabled(lvl zapcore.Level) bool {
+	return lvl >= l.min && l.LevelEnabler.Enabled(lvl)
+}
+
+// intValue 返回指针指向的值，nil 时为 0
+func intValue(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
@@ -0,0 +1,260 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// useTestLogPath 将日志目录替换为临时目录，并在结束时关闭测试创建的 logger
+func useTestLogPath(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	old := logPath
+	logPath = dir
+	t.Cleanup(func() {
+		_ = Close()
+		logPath = old
+	})
+	return dir
+}
+
+func TestGetOutputsLegacy(t *testing.T) {
+	useTestConfig(t, `
+log:
+  encode_type: mis
+  stdout_encode: json
+  maxsize: 10
+  max_backups: 3
+  compress: false
+`)
+	outputs, err := GetOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("expected file and stdout outputs, got %+v", outputs)
+	}
+	file, stdout := outputs[0], outputs[1]
+	if file.Type != OutputFile || file.EncodeType != "mis" || file.MaxSize != 10 || *file.MaxBackups != 3 || *file.Compress {
+		t.Errorf("unexpected file output %+v", file)
+	}
+	if stdout.Type != OutputStdout || stdout.EncodeType != "json" {
+		t.Errorf("unexpected stdout output %+v", stdout)
+	}
+}
+
+func TestGetOutputs(t *testing.T) {
+	useTestConfig(t, `
+log:
+  encode_type: mis
+  max_age: 7
+  outputs:
+    - filename: app-{name}.log
+      rotate: daily
+    - type: stderr
+      level: error
+    - type: tcp
+      address: 127.0.0.1:5000
+    - filename: keep.log
+      max_age: 0
+`)
+	outputs, err := GetOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 4 {
+		t.Fatalf("expected 4 outputs, got %+v", outputs)
+	}
+	if o := outputs[0]; o.Type != OutputFile || o.Rotate != RotateDaily || o.EncodeType != "mis" || *o.MaxAge != 7 || o.MaxSize != defaultMaxSize {
+		t.Errorf("unexpected file output %+v", o)
+	}
+	if o := outputs[1]; o.EncodeType != "console" || o.Level != "error" {
+		t.Errorf("unexpected stderr output %+v", o)
+	}
+	// 网络输出默认为 json，不使用 log.encode_type
+	if o := outputs[2]; o.EncodeType != "json" || o.Address != "127.0.0.1:5000" {
+		t.Errorf("unexpected tcp output %+v", o)
+	}
+	// 显式配置为 0 时不使用 log.max_age
+	if o := outputs[3]; *o.MaxAge != 0 {
+		t.Errorf("expected max_age 0 override, got %d", *o.MaxAge)
+	}
+}
+
+func TestNewLoggerOutputs(t *testing.T) {
+	dir := useTestLogPath(t)
+	useTestConfig(t, `
+log:
+  level: debug
+  outputs:
+    - filename: "{name}.log"
+    - filename: "{name}.error.log"
+      level: error
+`)
+	l := NewLogger("outputs")
+	l.Info("info message")
+	l.Error("error message")
+
+	all, _ := os.ReadFile(filepath.Join(dir, "outputs.log"))
+	errs, _ := os.ReadFile(filepath.Join(dir, "outputs.error.log"))
+	if !strings.Contains(string(all), "info message") || !strings.Contains(string(all), "error message") {
+		t.Errorf("outputs.log expected both messages, got %q", all)
+	}
+	if strings.Contains(string(errs), "info message") || !strings.Contains(string(errs), "error message") {
+		t.Errorf("outputs.error.log expected only error message, got %q", errs)
+	}
+}
+
+func TestNetOutputs(t *testing.T) {
+	useTestLogPath(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	tcpLines := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		tcpLines <- line
+	}()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	useTestConfig(t, `
+log:
+  outputs:
+    - type: tcp
+      address: `+ln.Addr().String()+`
+    - type: udp
+      address: `+pc.LocalAddr().String()+`
+`)
+	NewLogger("net").Warn("shipped", zap.String("k", "v"))
+
+	select {
+	case line := <-tcpLines:
+		if m := decodeLine(t, line); m["msg"] != "shipped" || m["k"] != "v" {
+			t.Errorf("unexpected tcp line %v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("tcp output timeout")
+	}
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := decodeLine(t, string(buf[:n])); m["msg"] != "shipped" {
+		t.Errorf("unexpected udp datagram %v", m)
+	}
+}
+
+func TestNetWriterReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			lines <- line
+			conn.Close()
+		}
+	}()
+
+	w := newNetWriter("tcp", ln.Addr().String())
+	defer w.Close()
+	if _, err := w.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line := <-lines; line != "first\n" {
+		t.Fatalf("unexpected line %q", line)
+	}
+
+	// 对端关闭后写入可能先成功，持续写入直到重连后被接收
+	deadline := time.After(2 * time.Second)
+	for {
+		_, _ = w.Write([]byte("second\n"))
+		select {
+		case line := <-lines:
+			if line != "second\n" {
+				t.Fatalf("unexpected line %q", line)
+			}
+			return
+		case <-deadline:
+			t.Fatal("reconnect timeout")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestNetWriterUnreachable(t *testing.T) {
+	// 先占用端口再关闭，得到一个没有监听的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := newNetWriter("tcp", addr)
+	defer w.Close()
+	before := Dropped()
+	start := time.Now()
+	for i := 0; i < netPendingLines+10; i++ {
+		if _, err := w.Write([]byte(fmt.Sprintf("line %d\n", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("writes blocked for %v while disconnected", elapsed)
+	}
+	if Dropped()-before < 10 {
+		t.Errorf("expected overflowed lines counted as dropped, got %d", Dropped()-before)
+	}
+
+	// 采集器恢复后发送缓存的日志
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("listen %s: %v", addr, err)
+	}
+	defer ln.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "line 10\n" {
+		t.Errorf("expected oldest buffered line first, got %q", line)
+	}
+}
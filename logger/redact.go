@@ -0,0 +1,662 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qkzsky/gutils/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// 脱敏方式
+const (
+	// RedactMask 替换为 ******
+	RedactMask = "mask"
+	// RedactHash 替换为 sha256 摘要的前 16 位，相同的值摘要相同，便于关联排查
+	RedactHash = "hash"
+	// RedactDrop 删除字段，值匹配时删除匹配的内容
+	RedactDrop = "drop"
+)
+
+const redactMaskValue = "******"
+
+// RedactRule log.redact 中的一条脱敏规则
+type RedactRule struct {
+	// Fields 字段名，不区分大小写，支持 * 与 ? 通配，如 password、*_token
+	// 同时匹配嵌套对象中的字段，以及字符串与 SQL 中的 password = 'xxx'、password: xxx、(password) VALUES ('xxx')
+	Fields []string `yaml:"fields"`
+	// Values 值的正则表达式，如卡号、邮箱，匹配字符串值与日志内容中的片段
+	Values []string `yaml:"values"`
+	// Action 脱敏方式：mask/hash/drop
+	Action string `yaml:"action" default:"mask"`
+}
+
+func init() {
+	config.AddRule(config.OneOf("log.redact.*.action", RedactMask, RedactHash, RedactDrop))
+}
+
+type redactRule struct {
+	fields []string
+	values []*regexp.Regexp
+	action string
+	// assign 字符串中字段赋值的写法，如 password = 'xxx'、"password":"xxx"、password=xxx
+	assign *regexp.Regexp
+}
+
+// redactor 按规则脱敏日志字段与内容
+type redactor struct {
+	rules []redactRule
+}
+
+// getRedactor 返回 log.redact 配置的脱敏规则，未配置时为 nil
+func getRedactor() (*redactor, error) {
+	var rules []RedactRule
+	if err := config.UnmarshalKey("log.redact", &rules); err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return newRedactor(rules)
+}
+
+func newRedactor(rules []RedactRule) (*redactor, error) {
+	r := &redactor{}
+	for _, rule := range rules {
+		rr := redactRule{action: rule.Action}
+		if rr.action == "" {
+			rr.action = RedactMask
+		}
+
+		alts := make([]string, 0, len(rule.Fields))
+		for _, f := range rule.Fields {
+			f = strings.ToLower(f)
+			if _, err := path.Match(f, ""); err != nil {
+				return nil, fmt.Errorf("logger: redact field %q: %w", f, err)
+			}
+			rr.fields = append(rr.fields, f)
+			alt := regexp.QuoteMeta(f)
+			alt = strings.ReplaceAll(alt, `\*`, `\w*`)
+			alt = strings.ReplaceAll(alt, `\?`, `\w`)
+			alts = append(alts, alt)
+		}
+		if len(alts) > 0 {
+			rr.assign = regexp.MustCompile("(?i)([`\"']?\\b(?:" + strings.Join(alts, "|") + ")\\b[`\"']?\\s*[=:]\\s*)" +
+				`('(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|[^\s,;&)'"]+)`)
+		}
+
+		for _, v := range rule.Values {
+			re, err := regexp.Compile(v)
+			if err != nil {
+				return nil, fmt.Errorf("logger: redact value %q: %w", v, err)
+			}
+			rr.values = append(rr.values, re)
+		}
+		r.rules = append(r.rules, rr)
+	}
+	return r, nil
+}
+
+// replace 按脱敏方式返回替换后的值
+func (r *redactor) replace(action, v string) string {
+	switch action {
+	case RedactHash:
+		sum := sha256.Sum256([]byte(v))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	case RedactDrop:
+		return ""
+	default:
+		return redactMaskValue
+	}
+}
+
+// fieldAction 字段名匹配的脱敏方式，不匹配时返回空
+func (r *redactor) fieldAction(key string) string {
+	key = strings.ToLower(key)
+	for _, rule := range r.rules {
+		for _, f := range rule.fields {
+			if ok, _ := path.Match(f, key); ok {
+				return rule.action
+			}
+		}
+	}
+	return ""
+}
+
+// redactString 脱敏字符串中的字段赋值、SQL 字面量与匹配的值
+func (r *redactor) redactString(s string) string {
+	for _, rule := range r.rules {
+		if rule.assign != nil {
+			s = r.redactAssign(s, rule)
+			s = r.redactInsert(s, rule)
+		}
+		for _, re := range rule.values {
+			if !re.MatchString(s) {
+				continue
+			}
+			s = re.ReplaceAllStringFunc(s, func(m string) string {
+				return r.replace(rule.action, m)
+			})
+		}
+	}
+	return s
+}
+
+func (r *redactor) redactAssign(s string, rule redactRule) string {
+	matches := rule.assign.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		// m[4]:m[5] 为值
+		b.WriteString(s[last:m[4]])
+		b.WriteString(r.replaceLiteral(rule.action, s[m[4]:m[5]]))
+		last = m[5]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// replaceLiteral 替换 SQL 字面量，保留引号
+func (r *redactor) replaceLiteral(action, lit string) string {
+	if n := len(lit); n >= 2 && (lit[0] == '\'' || lit[0] == '"') && lit[n-1] == lit[0] {
+		return lit[:1] + r.replace(action, lit[1:n-1]) + lit[:1]
+	}
+	return r.replace(action, lit)
+}
+
+var insertColumns = regexp.MustCompile(`(?is)\(([^()]+)\)\s*VALUES\s*`)
+
+// redactInsert 脱敏 INSERT INTO t (a, password) VALUES ('x', 'y'), (...) 中匹配字段对应的值
+func (r *redactor) redactInsert(s string, rule redactRule) string {
+	loc := insertColumns.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return s
+	}
+
+	var positions []int
+	for i, col := range strings.Split(s[loc[2]:loc[3]], ",") {
+		col = strings.Trim(strings.TrimSpace(col), "`\"'")
+		for _, f := range rule.fields {
+			if ok, _ := path.Match(f, strings.ToLower(col)); ok {
+				positions = append(positions, i)
+				break
+			}
+		}
+	}
+	if len(positions) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(s[:loc[1]])
+	i := loc[1]
+	for i < len(s) && s[i] == '(' {
+		values, end := splitTuple(s, i+1)
+		if end < 0 {
+			break
+		}
+		for _, p := range positions {
+			if p < len(values) {
+				values[p] = r.replaceLiteral(rule.action, values[p])
+			}
+		}
+		b.WriteString("(" + strings.Join(values, ", ") + ")")
+		i = end + 1
+		// 批量插入的下一组值
+		j := i
+		for j < len(s) && (s[j] == ',' || s[j] == ' ' || s[j] == '\n' || s[j] == '\t') {
+			j++
+		}
+		if j < len(s) && s[j] == '(' {
+			b.WriteString(s[i:j])
+			i = j
+		}
+	}
+	b.WriteString(s[i:])
+	return b.String()
+}
+
+// splitTuple 从 start 开始按逗号拆分括号内的值，返回各值与右括号的位置，括号未闭合时 end 为 -1
+func splitTuple(s string, start int) (values []string, end int) {
+	depth, from := 0, start
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			values = append(values, strings.TrimSpace(s[from:i]))
+			from = i + 1
+		case c == ')':
+			return append(values, strings.TrimSpace(s[from:i])), i
+		}
+	}
+	return nil, -1
+}
+
+// redactValue 脱敏 AddReflected 的对象：转换为 json 结构后处理嵌套字段与字符串
+func (r *redactor) redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			switch action := r.fieldAction(k); action {
+			case "":
+				val[k] = r.redactValue(child)
+			case RedactDrop:
+				delete(val, k)
+			default:
+				val[k] = r.replace(action, fmt.Sprint(child))
+			}
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = r.redactValue(child)
+		}
+		return val
+	case string:
+		return r.redactString(val)
+	default:
+		return v
+	}
+}
+
+// redactEncoder 在 JSON、console、MIS 编码器外层脱敏字段与日志内容
+type redactEncoder struct {
+	redactObjectEncoder
+	enc zapcore.Encoder
+}
+
+func newRedactEncoder(enc zapcore.Encoder, r *redactor) *redactEncoder {
+	return &redactEncoder{redactObjectEncoder: redactObjectEncoder{ObjectEncoder: enc, r: r}, enc: enc}
+}
+
+func (e *redactEncoder) Clone() zapcore.Encoder {
+	return newRedactEncoder(e.enc.Clone(), e.r)
+}
+
+// EncodeEntry 字段经过脱敏后转换为新的字段，再由原编码器输出，不需要为每条日志克隆编码器
+func (e *redactEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	rec := fieldRecorderPool.Get().(*fieldRecorder)
+	rec.re = redactObjectEncoder{ObjectEncoder: rec, r: e.r}
+	for _, f := range fields {
+		f.AddTo(&rec.re)
+	}
+	ent.Message = e.r.redactString(ent.Message)
+	buf, err := e.enc.EncodeEntry(ent, rec.fields)
+	rec.reset()
+	fieldRecorderPool.Put(rec)
+	return buf, err
+}
+
+var fieldRecorderPool = sync.Pool{New: func() interface{} { return &fieldRecorder{} }}
+
+// fieldRecorder 将写入的字段记录为 zapcore.Field
+type fieldRecorder struct {
+	fields []zapcore.Field
+	// re 脱敏后写入 fieldRecorder，随 fieldRecorder 复用
+	re redactObjectEncoder
+}
+
+func (r *fieldRecorder) reset() {
+	clear(r.fields)
+	r.fields = r.fields[:0]
+	r.re = redactObjectEncoder{}
+}
+
+func (r *fieldRecorder) add(f zapcore.Field) {
+	r.fields = append(r.fields, f)
+}
+
+func (r *fieldRecorder) AddArray(key string, m zapcore.ArrayMarshaler) error {
+	r.add(zap.Array(key, m))
+	return nil
+}
+
+func (r *fieldRecorder) AddObject(key string, m zapcore.ObjectMarshaler) error {
+	r.add(zap.Object(key, m))
+	return nil
+}
+
+func (r *fieldRecorder) AddReflected(key string, value interface{}) error {
+	r.add(zap.Reflect(key, value))
+	return nil
+}
+
+func (r *fieldRecorder) AddBinary(key string, value []byte) {
+	r.add(zap.Binary(key, value))
+}
+
+func (r *fieldRecorder) AddByteString(key string, value []byte) {
+	r.add(zap.ByteString(key, value))
+}
+
+func (r *fieldRecorder) AddBool(key string, value bool) {
+	r.add(zap.Bool(key, value))
+}
+
+func (r *fieldRecorder) AddComplex128(key string, value complex128) {
+	r.add(zap.Complex128(key, value))
+}
+
+func (r *fieldRecorder) AddComplex64(key string, value complex64) {
+	r.add(zap.Complex64(key, value))
+}
+
+func (r *fieldRecorder) AddDuration(key string, value time.Duration) {
+	r.add(zap.Duration(key, value))
+}
+
+func (r *fieldRecorder) AddFloat64(key string, value float64) {
+	r.add(zap.Float64(key, value))
+}
+
+func (r *fieldRecorder) AddFloat32(key string, value float32) {
+	r.add(zap.Float32(key, value))
+}
+
+func (r *fieldRecorder) AddInt(key string, value int) {
+	r.add(zap.Int(key, value))
+}
+
+func (r *fieldRecorder) AddInt64(key string, value int64) {
+	r.add(zap.Int64(key, value))
+}
+
+func (r *fieldRecorder) AddInt32(key string, value int32) {
+	r.add(zap.Int32(key, value))
+}
+
+func (r *fieldRecorder) AddInt16(key string, value int16) {
+	r.add(zap.Int16(key, value))
+}
+
+func (r *fieldRecorder) AddInt8(key string, value int8) {
+	r.add(zap.Int8(key, value))
+}
+
+func (r *fieldRecorder) AddString(key, value string) {
+	r.add(zap.String(key, value))
+}
+
+func (r *fieldRecorder) AddTime(key string, value time.Time) {
+	r.add(zap.Time(key, value))
+}
+
+func (r *fieldRecorder) AddUint(key string, value uint) {
+	r.add(zap.Uint(key, value))
+}
+
+func (r *fieldRecorder) AddUint64(key string, value uint64) {
+	r.add(zap.Uint64(key, value))
+}
+
+func (r *fieldRecorder) AddUint32(key string, value uint32) {
+	r.add(zap.Uint32(key, value))
+}
+
+func (r *fieldRecorder) AddUint16(key string, value uint16) {
+	r.add(zap.Uint16(key, value))
+}
+
+func (r *fieldRecorder) AddUint8(key string, value uint8) {
+	r.add(zap.Uint8(key, value))
+}
+
+func (r *fieldRecorder) AddUintptr(key string, value uintptr) {
+	r.add(zap.Uintptr(key, value))
+}
+
+func (r *fieldRecorder) OpenNamespace(key string) {
+	r.add(zap.Namespace(key))
+}
+
+// redactObjectEncoder 脱敏写入的字段，嵌套对象与数组同样经过脱敏
+type redactObjectEncoder struct {
+	zapcore.ObjectEncoder
+	r *redactor
+}
+
+// redactKey 字段名匹配时按规则写入，返回 true 表示已处理
+func (e *redactObjectEncoder) redactKey(key string, v interface{}) bool {
+	action := e.r.fieldAction(key)
+	switch action {
+	case "":
+		return false
+	case RedactDrop:
+	default:
+		e.ObjectEncoder.AddString(key, e.r.replace(action, fmt.Sprint(v)))
+	}
+	return true
+}
+
+func (e *redactObjectEncoder) AddString(key, value string) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddString(key, e.r.redactString(value))
+	}
+}
+
+func (e *redactObjectEncoder) AddByteString(key string, value []byte) {
+	if !e.redactKey(key, string(value)) {
+		e.ObjectEncoder.AddString(key, e.r.redactString(string(value)))
+	}
+}
+
+func (e *redactObjectEncoder) AddBinary(key string, value []byte) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddBinary(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddObject(key string, m zapcore.ObjectMarshaler) error {
+	if e.redactKey(key, m) {
+		return nil
+	}
+	return e.ObjectEncoder.AddObject(key, redactObjectMarshaler{m: m, r: e.r})
+}
+
+func (e *redactObjectEncoder) AddArray(key string, m zapcore.ArrayMarshaler) error {
+	if e.redactKey(key, m) {
+		return nil
+	}
+	return e.ObjectEncoder.AddArray(key, redactArrayMarshaler{m: m, r: e.r})
+}
+
+func (e *redactObjectEncoder) AddReflected(key string, value interface{}) error {
+	if e.redactKey(key, value) {
+		return nil
+	}
+	return e.ObjectEncoder.AddReflected(key, e.r.redactReflected(value))
+}
+
+// redactReflected 通过 json 转换为通用结构后脱敏，无法转换时原样返回
+// 数字按 json.Number 保留原文，避免大整数转换为 float64 丢失精度
+func (r *redactor) redactReflected(value interface{}) interface{} {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return value
+	}
+	return r.redactValue(generic)
+}
+
+func (e *redactObjectEncoder) AddBool(key string, value bool) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddBool(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddComplex128(key string, value complex128) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddComplex128(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddComplex64(key string, value complex64) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddComplex64(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddDuration(key string, value time.Duration) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddDuration(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddFloat64(key string, value float64) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddFloat64(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddFloat32(key string, value float32) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddFloat32(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt(key string, value int) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddInt(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt64(key string, value int64) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddInt64(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt32(key string, value int32) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddInt32(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt16(key string, value int16) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddInt16(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddInt8(key string, value int8) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddInt8(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddTime(key string, value time.Time) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddTime(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint(key string, value uint) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddUint(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint64(key string, value uint64) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddUint64(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint32(key string, value uint32) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddUint32(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint16(key string, value uint16) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddUint16(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUint8(key string, value uint8) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddUint8(key, value)
+	}
+}
+
+func (e *redactObjectEncoder) AddUintptr(key string, value uintptr) {
+	if !e.redactKey(key, value) {
+		e.ObjectEncoder.AddUintptr(key, value)
+	}
+}
+
+type redactObjectMarshaler struct {
+	m zapcore.ObjectMarshaler
+	r *redactor
+}
+
+func (m redactObjectMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return m.m.MarshalLogObject(&redactObjectEncoder{ObjectEncoder: enc, r: m.r})
+}
+
+type redactArrayMarshaler struct {
+	m zapcore.ArrayMarshaler
+	r *redactor
+}
+
+func (m redactArrayMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return m.m.MarshalLogArray(&redactArrayEncoder{ArrayEncoder: enc, r: m.r})
+}
+
+// redactArrayEncoder 脱敏数组中的字符串与嵌套对象
+type redactArrayEncoder struct {
+	zapcore.ArrayEncoder
+	r *redactor
+}
+
+func (e *redactArrayEncoder) AppendString(value string) {
+	e.ArrayEncoder.AppendString(e.r.redactString(value))
+}
+
+func (e *redactArrayEncoder) AppendByteString(value []byte) {
+	e.ArrayEncoder.AppendString(e.r.redactString(string(value)))
+}
+
+func (e *redactArrayEncoder) AppendObject(m zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(redactObjectMarshaler{m: m, r: e.r})
+}
+
+func (e *redactArrayEncoder) AppendArray(m zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(redactArrayMarshaler{m: m, r: e.r})
+}
+
+func (e *redactArrayEncoder) AppendReflected(value interface{}) error {
+	return e.ArrayEncoder.AppendReflected(e.r.redactReflected(value))
+}
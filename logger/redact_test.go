@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+func (c credentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("user", c.User)
+	enc.AddString("password", c.Password)
+	return nil
+}
+
+func testRedactor(t *testing.T) *redactor {
+	t.Helper()
+	r, err := newRedactor([]RedactRule{
+		{Fields: []string{"password", "authorization", "*_token"}},
+		{Fields: []string{"email"}, Values: []string{`[\w.+-]+@[\w-]+\.[\w.]+`}, Action: RedactHash},
+		{Values: []string{`\b\d{16}\b`}},
+		{Fields: []string{"secret"}, Action: RedactDrop},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRedactString(t *testing.T) {
+	r := testRedactor(t)
+	hash := r.replace(RedactHash, "a@b.com")
+
+	for in, expected := range map[string]string{
+		"UPDATE `users` SET `password`='p@ss' WHERE id = 1":                                     "UPDATE `users` SET `password`='******' WHERE id = 1",
+		`SELECT * FROM users WHERE email = "a@b.com" AND api_token = 'x' LIMIT 1`:               `SELECT * FROM users WHERE email = "` + hash + `" AND api_token = '******' LIMIT 1`,
+		"INSERT INTO `users` (`name`,`password`,`age`) VALUES ('bob','p1',18),('amy','p,2',20)": "INSERT INTO `users` (`name`,`password`,`age`) VALUES ('bob', '******', 18),('amy', '******', 20)",
+		`{"user":"bob","Authorization":"Bearer abc"}`:                                           `{"user":"bob","Authorization":"******"}`,
+		"card 4111111111111111 paid":                                                            "card ****** paid",
+		"GET /login?access_token=abc&x=1":                                                       "GET /login?access_token=******&x=1",
+		"nothing sensitive":                                                                     "nothing sensitive",
+	} {
+		if got := r.redactString(in); got != expected {
+			t.Errorf("redactString(%q)\n expected %q\n      got %q", in, expected, got)
+		}
+	}
+}
+
+func TestRedactEncoder(t *testing.T) {
+	r := testRedactor(t)
+	for _, tc := range []struct {
+		name string
+		enc  zapcore.Encoder
+	}{
+		{"json", zapcore.NewJSONEncoder(GetEncoder())},
+		{"console", zapcore.NewConsoleEncoder(GetEncoder())},
+		{"mis", NewMisEncoder(GetEncoder())},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := useTestLogger(t, newRedactEncoder(tc.enc, r))
+			l := FromContext(t.Context()).With(zap.String("refresh_token", "rt"), zap.String("request_id", "req-1"))
+			l.Info("login 4111111111111111",
+				zap.String("password", "p1"),
+				zap.Int("secret", 987654),
+				zap.Object("creds", credentials{User: "bob", Password: "p2"}),
+				zap.Any("body", map[string]interface{}{"nested": map[string]interface{}{"password": "p3", "email": "a@b.com"}}),
+				zap.Strings("tags", []string{"4111111111111111"}),
+				zap.Error(errors.New("auth failed for password=p4")),
+				zap.String("user", "bob"),
+			)
+
+			out := buf.String()
+			for _, leaked := range []string{"p1", "p2", "p3", "p4", "rt", "a@b.com", "4111111111111111", "secret", "987654"} {
+				if strings.Contains(out, leaked) {
+					t.Errorf("%q leaked in %s", leaked, out)
+				}
+			}
+			for _, kept := range []string{"bob", "login", redactMaskValue, "sha256:"} {
+				if !strings.Contains(out, kept) {
+					t.Errorf("expected %q in %s", kept, out)
+				}
+			}
+			if tc.name == "mis" && !strings.Contains(out, "[req-1]") {
+				t.Errorf("expected mis logID kept, got %s", out)
+			}
+		})
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	dir := useTestLogPath(t)
+	useTestConfig(t, `
+log:
+  redact:
+    - fields: [password]
+      action: mask
+`)
+	NewLogger("redact").Info("user created", zap.String("password", "hunter2"))
+
+	content, _ := os.ReadFile(filepath.Join(dir, "redact.log"))
+	if strings.Contains(string(content), "hunter2") || !strings.Contains(string(content), `"password":"******"`) {
+		t.Errorf("expected password masked, got %q", content)
+	}
+
+	if _, err := newRedactor([]RedactRule{{Values: []string{"("}}}); err == nil {
+		t.Error("expected invalid regexp error")
+	}
+}
+
+func TestRedactReflectedNumbers(t *testing.T) {
+	r := testRedactor(t)
+	buf := useTestLogger(t, newRedactEncoder(zapcore.NewJSONEncoder(GetEncoder()), r))
+	FromContext(t.Context()).Info("order", zap.Any("body", map[string]interface{}{"id": int64(1<<62 + 1), "password": "p1"}))
+
+	out := buf.String()
+	if !strings.Contains(out, `"id":4611686018427387905`) || strings.Contains(out, "p1") {
+		t.Errorf("unexpected output %s", out)
+	}
+}
+
+func TestRedactEncoderAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("race detector allocates")
+	}
+	enc := newRedactEncoder(zapcore.NewJSONEncoder(GetEncoder()), testRedactor(t))
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "hello"}
+	fields := []zapcore.Field{zap.String("user", "bob"), zap.Int("n", 1), zap.String("password", "p1")}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, err := enc.EncodeEntry(ent, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Free()
+	})
+	// 不克隆编码器，只有字段值转换为 interface{} 时分配
+	if allocs > float64(len(fields)) {
+		t.Errorf("expected at most %d allocations, got %v", len(fields), allocs)
+	}
+}
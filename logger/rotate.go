@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeRotateWriter 按天或按小时切割的日志文件
+// 当前写入 filename，切割后重命名为 filename.2006-01-02 或 filename.2006-01-02-15
+type timeRotateWriter struct {
+	mu       sync.Mutex
+	filename string
+	layout   string
+	// maxBackups 保留的历史文件数，maxAge 历史文件保留天数，0 为不限制
+	maxBackups int
+	maxAge     int
+
+	file   *os.File
+	period string
+	now    func() time.Time
+}
+
+func newTimeRotateWriter(filename, rotate string, maxBackups, maxAge int) *timeRotateWriter {
+	layout := "2006-01-02"
+	if rotate == RotateHourly {
+		layout = "2006-01-02-15"
+	}
+	return &timeRotateWriter{
+		filename:   filename,
+		layout:     layout,
+		maxBackups: maxBackups,
+		maxAge:     maxAge,
+		now:        time.Now,
+	}
+}
+
+func (w *timeRotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	period := w.now().Format(w.layout)
+	if w.file == nil {
+		if err := w.open(period); err != nil {
+			return 0, err
+		}
+	} else if period != w.period {
+		if err := w.rotate(period); err != nil {
+			return 0, err
+		}
+	}
+	return w.file.Write(p)
+}
+
+// open 打开当前文件，已有文件属于之前的周期时先切割
+func (w *timeRotateWriter) open(period string) error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0o755); err != nil {
+		return err
+	}
+	if info, err := os.Stat(w.filename); err == nil {
+		if old := info.ModTime().Format(w.layout); old != period {
+			if err := w.backup(old); err != nil {
+				return err
+			}
+		}
+	}
+
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.file, w.period = f, period
+	return nil
+}
+
+func (w *timeRotateWriter) rotate(period string) error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	if err := w.backup(w.period); err != nil {
+		return err
+	}
+	return w.open(period)
+}
+
+// backup 将当前文件重命名为历史文件并清理过期的历史文件
+func (w *timeRotateWriter) backup(period string) error {
+	name := w.filename + "." + period
+	// 同一周期已有历史文件（如进程重启后时间回拨）时追加序号
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s.%s.%d", w.filename, period, i)
+	}
+	if err := os.Rename(w.filename, name); err != nil {
+		return err
+	}
+	go w.cleanup(w.now())
+	return nil
+}
+
+// cleanup 删除超过 maxAge 天或超出 maxBackups 个数的历史文件
+func (w *timeRotateWriter) cleanup(now time.Time) {
+	if w.maxBackups == 0 && w.maxAge == 0 {
+		return
+	}
+	matches, err := filepath.Glob(w.filename + ".*")
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		name string
+		t    time.Time
+	}
+	var backups []backup
+	prefix := filepath.Base(w.filename) + "."
+	for _, m := range matches {
+		suffix := strings.TrimPrefix(filepath.Base(m), prefix)
+		if len(suffix) < len(w.layout) {
+			continue
+		}
+		t, err := time.ParseInLocation(w.layout, suffix[:len(w.layout)], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{name: m, t: t})
+	}
+	// 新文件在前
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].t.Equal(backups[j].t) {
+			return backups[i].name > backups[j].name
+		}
+		return backups[i].t.After(backups[j].t)
+	})
+
+	cutoff := now.AddDate(0, 0, -w.maxAge)
+	for i, b := range backups {
+		if (w.maxBackups > 0 && i >= w.maxBackups) || (w.maxAge > 0 && b.t.Before(cutoff)) {
+			_ = os.Remove(b.name)
+		}
+	}
+}
+
+func (w *timeRotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+func (w *timeRotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestTimeRotateWriter(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+
+	w := newTimeRotateWriter(filename, RotateDaily, 2, 0)
+	w.now = func() time.Time { return now }
+	defer w.Close()
+
+	for day := 0; day < 4; day++ {
+		now = time.Date(2024, 3, 1+day, 10, 0, 0, 0, time.Local)
+		if _, err := w.Write([]byte(now.Format("2006-01-02") + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 清理在后台执行
+	var names []string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		matches, _ := filepath.Glob(filepath.Join(dir, "app.log*"))
+		names = names[:0]
+		for _, m := range matches {
+			names = append(names, filepath.Base(m))
+		}
+		if len(names) == 3 {
+			break
+		}
+	}
+	sort.Strings(names)
+	expected := []string{"app.log", "app.log.2024-03-02", "app.log.2024-03-03"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, names)
+		}
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "app.log.2024-03-03"))
+	if string(content) != "2024-03-03\n" {
+		t.Errorf("unexpected backup content %q", content)
+	}
+}
+
+func TestTimeRotateWriterMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	for _, name := range []string{"app.log.2024-03-01-08", "app.log.2024-02-20-08", "other.log.2024-02-20-08"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 已有的当前文件属于之前的周期，打开时切割
+	if err := os.WriteFile(filename, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 3, 2, 9, 30, 0, 0, time.Local)
+	if err := os.Chtimes(filename, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	w := newTimeRotateWriter(filename, RotateHourly, 0, 7)
+	w.now = func() time.Time { return time.Date(2024, 3, 2, 10, 0, 0, 0, time.Local) }
+	defer w.Close()
+	if _, err := w.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(filepath.Join(dir, "app.log.2024-02-20-08")); os.IsNotExist(err) {
+			break
+		}
+	}
+	for name, exists := range map[string]bool{
+		"app.log":                 true,
+		"app.log.2024-03-02-09":   true,
+		"app.log.2024-03-01-08":   true,
+		"app.log.2024-02-20-08":   false,
+		"other.log.2024-02-20-08": true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists != (err == nil) {
+			t.Errorf("%s expected exists=%v, got err %v", name, exists, err)
+		}
+	}
+}
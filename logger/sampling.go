@@ -0,0 +1,224 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/qkzsky/gutils/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig log.sampling 采样配置，每个 tick 内相同级别与内容的日志先输出 initial 条，之后每 thereafter 条输出一条
+type SamplingConfig struct {
+	Initial    int           `yaml:"initial"`
+	Thereafter int           `yaml:"thereafter" default:"100"`
+	Tick       time.Duration `yaml:"tick" default:"1s"`
+}
+
+// RateLimitConfig log.rate_limit 限流配置，每个 interval 内相同级别与内容的日志最多输出 levels 中配置的条数
+// 超出的日志在 interval 结束时汇总为一条 "suppressed N similar messages"
+// error 及以上级别至少输出一条，dpanic、panic、fatal 不限流
+type RateLimitConfig struct {
+	Interval time.Duration  `yaml:"interval" default:"1m"`
+	Levels   map[string]int `yaml:"levels"`
+}
+
+func init() {
+	config.AddRule(
+		config.Range("log.sampling.initial", 0, 1<<30),
+		// thereafter 为 0 时 initial 之后的日志全部丢弃
+		config.Range("log.sampling.thereafter", 1, 1<<30),
+		config.Range("log.rate_limit.levels.*", 0, 1<<30),
+	)
+}
+
+// GetSamplingConfig 返回 log.sampling 采样配置，Initial 为 0 时不采样
+func GetSamplingConfig() (SamplingConfig, error) {
+	var cfg SamplingConfig
+	err := config.UnmarshalKey("log.sampling", &cfg)
+	return cfg, err
+}
+
+// GetRateLimitConfig 返回 log.rate_limit 限流配置，Levels 为空时不限流
+func GetRateLimitConfig() (RateLimitConfig, error) {
+	var cfg RateLimitConfig
+	err := config.UnmarshalKey("log.rate_limit", &cfg)
+	return cfg, err
+}
+
+const (
+	defaultSamplingTick      = time.Second
+	defaultRateLimitInterval = time.Minute
+
+	// rateMaxKeys 一个周期内单独计数的日志内容上限，超出后其余内容按 logger 与级别合并计数
+	// 避免日志内容含请求参数等高基数数据时计数无限增长
+	rateMaxKeys = 10000
+	// rateOtherMsg 合并计数的日志在汇总中的 suppressed_msg
+	rateOtherMsg = "<other>"
+)
+
+// rateKey 限流按 logger、级别与日志内容计数
+type rateKey struct {
+	logger string
+	level  zapcore.Level
+	msg    string
+}
+
+// rateLimiter 同一 logger 的所有 rateLimitCore 共享计数，由后台 goroutine 定期输出汇总
+type rateLimiter struct {
+	root     zapcore.Core
+	limits   map[zapcore.Level]int
+	interval time.Duration
+
+	mu     sync.Mutex
+	counts map[rateKey]int
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newRateLimiter(root zapcore.Core, cfg RateLimitConfig) (*rateLimiter, error) {
+	limits := make(map[zapcore.Level]int, len(cfg.Levels))
+	for name, limit := range cfg.Levels {
+		var l zapcore.Level
+		if err := l.Set(name); err != nil {
+			return nil, fmt.Errorf("logger: rate_limit: %w", err)
+		}
+		if l >= zapcore.ErrorLevel && limit < 1 {
+			limit = 1
+		}
+		limits[l] = limit
+	}
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultRateLimitInterval
+	}
+	rl := &rateLimiter{
+		root:     root,
+		limits:   limits,
+		interval: interval,
+		counts:   map[rateKey]int{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go rl.run()
+	return rl, nil
+}
+
+func (rl *rateLimiter) run() {
+	defer close(rl.done)
+	ticker := time.NewTicker(rl.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rl.flush()
+		case <-rl.stop:
+			rl.flush()
+			return
+		}
+	}
+}
+
+// allow 判断日志是否在限额内
+func (rl *rateLimiter) allow(ent zapcore.Entry) bool {
+	limit, ok := rl.limits[ent.Level]
+	if !ok || ent.Level >= zapcore.DPanicLevel {
+		return true
+	}
+
+	key := rateKey{logger: ent.LoggerName, level: ent.Level, msg: ent.Message}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if _, ok := rl.counts[key]; !ok && len(rl.counts) >= rateMaxKeys {
+		key.msg = rateOtherMsg
+	}
+	rl.counts[key]++
+	return rl.counts[key] <= limit
+}
+
+// flush 输出本周期内被限流的日志汇总并重置计数
+func (rl *rateLimiter) flush() {
+	rl.mu.Lock()
+	counts := rl.counts
+	rl.counts = map[rateKey]int{}
+	rl.mu.Unlock()
+
+	now := time.Now()
+	for key, n := range counts {
+		suppressed := n - rl.limits[key.level]
+		if suppressed <= 0 {
+			continue
+		}
+		ent := zapcore.Entry{
+			LoggerName: key.logger,
+			Level:      key.level,
+			Time:       now,
+			Message:    fmt.Sprintf("suppressed %d similar messages", suppressed),
+		}
+		if ce := rl.root.Check(ent, nil); ce != nil {
+			ce.Write(
+				zap.String("suppressed_msg", key.msg),
+				zap.Int("suppressed", suppressed),
+				zap.Duration("interval", rl.interval),
+			)
+		}
+	}
+}
+
+// Close 停止后台 goroutine 并输出最后一次汇总
+func (rl *rateLimiter) Close() error {
+	rl.stopOnce.Do(func() { close(rl.stop) })
+	<-rl.done
+	return nil
+}
+
+// rateLimitCore 按 rateLimiter 的限额过滤日志
+type rateLimitCore struct {
+	zapcore.Core
+	rl *rateLimiter
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), rl: c.rl}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) || !c.rl.allow(ent) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// wrapCore 按 log.sampling 与 log.rate_limit 包装 logger 的 core，closer 用于停止限流的后台 goroutine
+func wrapCore(core zapcore.Core) (zapcore.Core, *rateLimiter, error) {
+	root := core
+
+	sampling, err := GetSamplingConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if sampling.Tick <= 0 {
+		sampling.Tick = defaultSamplingTick
+	}
+	if sampling.Initial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, sampling.Tick, sampling.Initial, sampling.Thereafter)
+	}
+
+	rateLimit, err := GetRateLimitConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rateLimit.Levels) == 0 {
+		return core, nil, nil
+	}
+	rl, err := newRateLimiter(root, rateLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &rateLimitCore{Core: core, rl: rl}, rl, nil
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampling(t *testing.T) {
+	dir := useTestLogPath(t)
+	useTestConfig(t, `
+log:
+  sampling:
+    initial: 2
+    thereafter: 3
+    tick: 1h
+`)
+	l := NewLogger("sampling")
+	for i := 0; i < 10; i++ {
+		l.Info("flood")
+	}
+	l.Info("other")
+
+	content, _ := os.ReadFile(filepath.Join(dir, "sampling.log"))
+	// 第 1、2 条，之后第 5、8 条
+	if n := strings.Count(string(content), `"msg":"flood"`); n != 4 {
+		t.Errorf("expected 4 sampled entries, got %d", n)
+	}
+	if !strings.Contains(string(content), `"msg":"other"`) {
+		t.Error("expected other message not sampled")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	rl, err := newRateLimiter(core, RateLimitConfig{
+		Interval: time.Hour,
+		Levels:   map[string]int{"info": 2, "error": 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := zap.New(&rateLimitCore{Core: core, rl: rl}).With(zap.String("k", "v"))
+
+	for i := 0; i < 5; i++ {
+		l.Info("redis timeout")
+	}
+	for i := 0; i < 3; i++ {
+		l.Error("db down")
+	}
+	for i := 0; i < 3; i++ {
+		l.Warn("not limited")
+	}
+	if n := logs.FilterMessage("redis timeout").Len(); n != 2 {
+		t.Errorf("expected 2 info entries, got %d", n)
+	}
+	// error 级别配置为 0 时仍保留一条
+	if n := logs.FilterMessage("db down").Len(); n != 1 {
+		t.Errorf("expected 1 error entry, got %d", n)
+	}
+	if n := logs.FilterMessage("not limited").Len(); n != 3 {
+		t.Errorf("expected 3 warn entries, got %d", n)
+	}
+	if entry := logs.FilterMessage("db down").All()[0]; entry.ContextMap()["k"] != "v" {
+		t.Errorf("expected With fields kept, got %v", entry.ContextMap())
+	}
+
+	// 关闭时输出汇总
+	_ = rl.Close()
+	for msg, expected := range map[string]struct {
+		level      zapcore.Level
+		suppressed int64
+	}{
+		"redis timeout": {zapcore.InfoLevel, 3},
+		"db down":       {zapcore.ErrorLevel, 2},
+	} {
+		summaries := logs.FilterField(zap.String("suppressed_msg", msg)).All()
+		if len(summaries) != 1 {
+			t.Fatalf("expected 1 summary for %q, got %d", msg, len(summaries))
+		}
+		s := summaries[0]
+		if s.Level != expected.level || s.ContextMap()["suppressed"] != expected.suppressed {
+			t.Errorf("unexpected summary for %q: %s %v", msg, s.Level, s.ContextMap())
+		}
+		if !strings.HasPrefix(s.Message, "suppressed ") {
+			t.Errorf("unexpected summary message %q", s.Message)
+		}
+	}
+
+	// 新周期重新计数
+	l.Info("redis timeout")
+	if n := logs.FilterMessage("redis timeout").Len(); n != 3 {
+		t.Errorf("expected counter reset after flush, got %d", n)
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	dir := useTestLogPath(t)
+	useTestConfig(t, `
+log:
+  rate_limit:
+    interval: 1h
+    levels:
+      warn: 1
+`)
+	l := NewLogger("ratelimit")
+	for i := 0; i < 4; i++ {
+		l.Warn("slow query")
+	}
+	if err := Close("ratelimit"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "ratelimit.log"))
+	if n := strings.Count(string(content), `"msg":"slow query"`); n != 1 {
+		t.Errorf("expected 1 entry, got %d", n)
+	}
+	if !strings.Contains(string(content), `"msg":"suppressed 3 similar messages"`) {
+		t.Errorf("expected summary written on close, got %q", content)
+	}
+}
+
+func TestRateLimitInvalidInterval(t *testing.T) {
+	core, _ := observer.New(zapcore.DebugLevel)
+	// interval 为 0 时使用默认值
+	rl, err := newRateLimiter(core, RateLimitConfig{Levels: map[string]int{"info": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rl.Close()
+	if rl.interval != defaultRateLimitInterval {
+		t.Errorf("expected default interval, got %s", rl.interval)
+	}
+}
+
+func TestRateLimitMaxKeys(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	rl, err := newRateLimiter(core, RateLimitConfig{Interval: time.Hour, Levels: map[string]int{"info": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := zap.New(&rateLimitCore{Core: core, rl: rl})
+
+	for i := 0; i < rateMaxKeys+10; i++ {
+		l.Info(fmt.Sprintf("user %d not found", i))
+	}
+	rl.mu.Lock()
+	n := len(rl.counts)
+	rl.mu.Unlock()
+	if n != rateMaxKeys+1 {
+		t.Errorf("expected %d keys, got %d", rateMaxKeys+1, n)
+	}
+	// 超出上限的日志合并计数，只输出一条
+	if n := logs.Len(); n != rateMaxKeys+1 {
+		t.Errorf("expected %d entries, got %d", rateMaxKeys+1, n)
+	}
+
+	_ = rl.Close()
+	summaries := logs.FilterField(zap.String("suppressed_msg", rateOtherMsg)).All()
+	if len(summaries) != 1 || summaries[0].ContextMap()["suppressed"] != int64(9) {
+		t.Errorf("unexpected summary %v", summaries)
+	}
+}
@@ -0,0 +1,207 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler 基于 zap logger 的 slog.Handler，日志与 zap 输出到相同的文件、使用相同的格式与级别
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(logger.NewLogger("app"))))
+type SlogHandler struct {
+	// logger 为 nil 时每次输出获取默认 logger，与第三方日志适配器相同，InitLogger 之前创建的 handler 同样有效
+	logger *zap.Logger
+	// fields WithAttrs 添加的字段（含分组对应的 zap.Namespace）
+	fields []zapcore.Field
+	// groups 尚未输出字段的分组，WithAttrs 或输出日志时才创建 zap.Namespace，避免空分组
+	groups []string
+	// cache 按 logger 缓存添加了 fields 的 core，默认 logger 替换后重新创建
+	cache atomic.Pointer[slogCore]
+}
+
+type slogCore struct {
+	base *zap.Logger
+	core zapcore.Core
+}
+
+// NewSlogHandler 创建 slog.Handler，l 为 nil 时使用默认 logger，默认 logger 未初始化时 info 及以上级别的日志输出到 stderr
+// 调用位置取自 slog.Record，context 中的追踪 ID 等字段同样会输出
+func NewSlogHandler(l *zap.Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+// resolve 返回当前使用的 logger 与 core，默认 logger 未初始化时返回 nil
+func (h *SlogHandler) resolve() (*zap.Logger, zapcore.Core) {
+	l := h.logger
+	if l == nil {
+		l = defaultLogger.Load()
+	}
+	if l == nil {
+		return nil, nil
+	}
+	if c := h.cache.Load(); c != nil && c.base == l {
+		return l, c.core
+	}
+	core := l.Core()
+	if len(h.fields) > 0 {
+		core = core.With(h.fields)
+	}
+	h.cache.Store(&slogCore{base: l, core: core})
+	return l, core
+}
+
+// slogLevel slog 级别对应的 zap 级别，slog 允许任意级别，按区间对应
+func slogLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case l < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case l < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	_, core := h.resolve()
+	if core == nil {
+		return slogLevel(level) >= zapcore.InfoLevel
+	}
+	return core.Enabled(slogLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	l, core := h.resolve()
+	if core == nil {
+		if level := slogLevel(r.Level); level >= zapcore.InfoLevel {
+			bridgeFallback.Printf("%s %s", level.CapitalString(), r.Message)
+		}
+		return nil
+	}
+
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	ent := zapcore.Entry{
+		Level:      slogLevel(r.Level),
+		Time:       r.Time,
+		LoggerName: l.Name(),
+		Message:    r.Message,
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ent.Caller.Function = frame.Function
+	}
+	ce := core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := ContextFields(ctx)
+	attrs := make([]zapcore.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendSlogAttr(attrs, a)
+		return true
+	})
+	if len(attrs) > 0 {
+		fields = append(fields, h.namespaces()...)
+		fields = append(fields, attrs...)
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zapcore.Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	with := append(append([]zapcore.Field(nil), h.fields...), h.namespaces()...)
+	return &SlogHandler{
+		logger: h.logger,
+		fields: append(with, fields...),
+	}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{
+		logger: h.logger,
+		fields: h.fields,
+		groups: append(append([]string(nil), h.groups...), name),
+	}
+}
+
+func (h *SlogHandler) namespaces() []zapcore.Field {
+	fields := make([]zapcore.Field, len(h.groups))
+	for i, g := range h.groups {
+		fields[i] = zap.Namespace(g)
+	}
+	return fields
+}
+
+// appendSlogAttr 转换为 zap 字段，忽略空 Attr，key 为空的分组展开到上一级
+func appendSlogAttr(fields []zapcore.Field, a slog.Attr) []zapcore.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			for _, ga := range group {
+				fields = appendSlogAttr(fields, ga)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(a.Key, slogGroup(group)))
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	default:
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var fields []zapcore.Field
+	for _, a := range g {
+		fields = appendSlogAttr(fields, a)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return nil
+}
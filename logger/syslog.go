@@ -0,0 +1,226 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"log/syslog"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern": syslog.LOG_KERN, "user": syslog.LOG_USER, "mail": syslog.LOG_MAIL,
+	"daemon": syslog.LOG_DAEMON, "auth": syslog.LOG_AUTH, "syslog": syslog.LOG_SYSLOG,
+	"lpr": syslog.LOG_LPR, "news": syslog.LOG_NEWS, "uucp": syslog.LOG_UUCP,
+	"cron": syslog.LOG_CRON, "authpriv": syslog.LOG_AUTHPRIV, "ftp": syslog.LOG_FTP,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3, "local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+// syslogCore 输出到 syslog，日志级别对应 syslog 的 severity
+type syslogCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	w   *syslogWriter
+}
+
+func newSyslogCore(o Output, logName string, enc zapcore.Encoder, level zapcore.LevelEnabler) (zapcore.Core, error) {
+	facility, ok := syslogFacilities[strings.ToLower(o.Facility)]
+	if !ok {
+		return nil, fmt.Errorf("logger: unknown syslog facility %q", o.Facility)
+	}
+	tag := o.Tag
+	if tag == "" {
+		tag = logName
+	}
+	return &syslogCore{LevelEnabler: level, enc: enc, w: newSyslogWriter(o.Network, o.Address, facility, tag)}, nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &syslogCore{LevelEnabler: c.LevelEnabler, enc: enc, w: c.w}
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	msg := strings.TrimSuffix(buf.String(), "\n")
+	buf.Free()
+	return c.w.write(syslogEntry{level: ent.Level, msg: msg})
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+func (c *syslogCore) Close() error {
+	return c.w.Close()
+}
+
+// syslogEntry 等待发送的一条日志
+type syslogEntry struct {
+	level zapcore.Level
+	msg   string
+}
+
+// syslogWriter 与 netWriter 相同，连接在后台建立，断开后按指数退避重连，期间日志缓存在内存中
+// syslog 不可用不影响启动与业务
+type syslogWriter struct {
+	mu       sync.Mutex
+	network  string
+	address  string
+	priority syslog.Priority
+	tag      string
+	w        *syslog.Writer
+	pending  []syslogEntry
+	dialing  bool
+	closed   bool
+	done     chan struct{}
+}
+
+func newSyslogWriter(network, address string, facility syslog.Priority, tag string) *syslogWriter {
+	w := &syslogWriter{network: network, address: address, priority: facility | syslog.LOG_INFO, tag: tag, done: make(chan struct{})}
+	w.mu.Lock()
+	w.reconnect()
+	w.mu.Unlock()
+	return w
+}
+
+func (w *syslogWriter) write(e syslogEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New("logger: syslog output closed")
+	}
+
+	if w.w == nil || w.send(e) != nil {
+		w.buffer(e)
+		w.reconnect()
+	}
+	return nil
+}
+
+// send 按级别写入当前连接，失败时关闭连接，需持有 mu
+func (w *syslogWriter) send(e syslogEntry) error {
+	var err error
+	switch e.level {
+	case zapcore.DebugLevel:
+		err = w.w.Debug(e.msg)
+	case zapcore.InfoLevel:
+		err = w.w.Info(e.msg)
+	case zapcore.WarnLevel:
+		err = w.w.Warning(e.msg)
+	case zapcore.ErrorLevel:
+		err = w.w.Err(e.msg)
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		err = w.w.Crit(e.msg)
+	case zapcore.FatalLevel:
+		err = w.w.Alert(e.msg)
+	default:
+		err = w.w.Info(e.msg)
+	}
+	if err != nil {
+		_ = w.w.Close()
+		w.w = nil
+	}
+	return err
+}
+
+// buffer 缓存未发送的日志，超过 netPendingLines 时丢弃最早的日志，需持有 mu
+func (w *syslogWriter) buffer(e syslogEntry) {
+	if len(w.pending) >= netPendingLines {
+		w.pending[0] = syslogEntry{}
+		w.pending = w.pending[1:]
+		dropped.Add(1)
+	}
+	w.pending = append(w.pending, e)
+}
+
+// reconnect 在后台建立连接，已在连接中时忽略，需持有 mu
+func (w *syslogWriter) reconnect() {
+	if w.dialing || w.closed {
+		return
+	}
+	w.dialing = true
+	go w.dial()
+}
+
+func (w *syslogWriter) dial() {
+	backoff := netMinBackoff
+	for {
+		sw, err := syslog.Dial(w.network, w.address, w.priority, w.tag)
+
+		w.mu.Lock()
+		if w.closed {
+			w.dialing = false
+			w.mu.Unlock()
+			if sw != nil {
+				_ = sw.Close()
+			}
+			return
+		}
+		if err == nil {
+			w.w, w.dialing = sw, false
+			w.flushPending()
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-time.After(backoff):
+		case <-w.done:
+		}
+		backoff = min(backoff*2, netMaxBackoff)
+	}
+}
+
+// flushPending 依次发送缓存的日志，连接再次断开时重新连接，需持有 mu
+func (w *syslogWriter) flushPending() {
+	for len(w.pending) > 0 {
+		if err := w.send(w.pending[0]); err != nil {
+			w.reconnect()
+			return
+		}
+		w.pending[0] = syslogEntry{}
+		w.pending = w.pending[1:]
+	}
+	w.pending = nil
+}
+
+// Close 关闭连接，尚未发送的日志计入 Dropped
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	dropped.Add(uint64(len(w.pending)))
+	w.pending = nil
+	if w.w == nil {
+		return nil
+	}
+	err := w.w.Close()
+	w.w = nil
+	return err
+}
@@ -0,0 +1,14 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"fmt"
+	"runtime"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func newSyslogCore(o Output, logName string, enc zapcore.Encoder, level zapcore.LevelEnabler) (zapcore.Core, error) {
+	return nil, fmt.Errorf("logger: syslog is not supported on %s", runtime.GOOS)
+}
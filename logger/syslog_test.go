@@ -0,0 +1,92 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogOutput(t *testing.T) {
+	useTestLogPath(t)
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	useTestConfig(t, `
+log:
+  outputs:
+    - type: syslog
+      network: udp
+      address: `+pc.LocalAddr().String()+`
+      tag: gutils
+      facility: local3
+`)
+	NewLogger("syslog").Error("disk full")
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// local3(19)*8 + err(3) = 155
+	if !strings.HasPrefix(msg, "<155>") || !strings.Contains(msg, "gutils") || !strings.Contains(msg, `"msg":"disk full"`) {
+		t.Errorf("unexpected syslog message %q", msg)
+	}
+}
+
+func TestSyslogUnreachable(t *testing.T) {
+	useTestLogPath(t)
+
+	// 先占用端口再关闭，得到一个没有监听的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	useTestConfig(t, `
+log:
+  outputs:
+    - type: syslog
+      network: tcp
+      address: `+addr+`
+      facility: local3
+`)
+	// syslog 不可用不影响创建 logger
+	l, err := NewLoggerE("syslog-down")
+	if err != nil {
+		t.Fatalf("expected logger created while syslog is down, got %v", err)
+	}
+	l.Warn("queued")
+
+	// syslog 恢复后发送缓存的日志
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("listen %s: %v", addr, err)
+	}
+	defer ln.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local3(19)*8 + warning(4) = 156
+	if !strings.HasPrefix(line, "<156>") || !strings.Contains(line, `"msg":"queued"`) {
+		t.Errorf("unexpected syslog message %q", line)
+	}
+}
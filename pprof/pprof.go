@@ -4,6 +4,9 @@ import (
 	"log"
 	"net/http"
 	"net/http/pprof"
+
+	"github.com/qkzsky/gutils/config"
+	"github.com/qkzsky/gutils/logger"
 )
 
 const (
@@ -11,6 +14,11 @@ const (
 	DefaultPrefix = "/debug/pprof"
 )
 
+func init() {
+	// pprof.log_level: off 不挂载日志级别接口，read 只允许查看，write 允许查看与修改
+	config.AddRule(config.OneOf("pprof.log_level", "off", "read", "write"))
+}
+
 func getPrefix(prefixOptions ...string) string {
 	prefix := DefaultPrefix
 	if len(prefixOptions) > 0 && len(prefixOptions[0]) > 0 {
@@ -34,6 +42,17 @@ func HttpServer(addr string, prefixOptions ...string) *http.Server {
 	mux.Handle(prefix+"/symbol", http.HandlerFunc(pprof.Symbol))
 	mux.Handle(prefix+"/threadcreate", pprof.Handler("threadcreate"))
 	mux.Handle(prefix+"/trace", http.HandlerFunc(pprof.Trace))
+	// 脱敏后的生效配置，?format=json 输出 json，需配置 pprof.expose_config: true
+	if config.GetBool("pprof.expose_config") {
+		mux.Handle(prefix+"/config", config.DumpHandler())
+	}
+	// 查看与修改日志级别，见 logger.LevelHandler，需配置 pprof.log_level
+	switch config.GetString("pprof.log_level") {
+	case "read":
+		mux.Handle(prefix+"/log/level", readOnly(logger.LevelHandler()))
+	case "write":
+		mux.Handle(prefix+"/log/level", logger.LevelHandler())
+	}
 
 	return &http.Server{
 		Addr:    addr,
@@ -41,6 +60,18 @@ func HttpServer(addr string, prefixOptions ...string) *http.Server {
 	}
 }
 
+// readOnly 只允许 GET 与 HEAD 请求
+func readOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
 func Listen(addr string, prefixOptions ...string) {
 	srv := HttpServer(addr, prefixOptions...)
 	go func() {
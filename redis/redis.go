@@ -11,6 +11,7 @@ import (
 	"github.com/qkzsky/gutils/config"
 	"github.com/qkzsky/gutils/logger"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap/zapcore"
 )
 
 const (
@@ -44,6 +45,24 @@ var (
 	mu       sync.RWMutex
 )
 
+func init() {
+	config.AddRule(
+		// port 未配置时默认为 6379，只要求配置 host
+		config.Required("redis.*.host"),
+		config.Range("redis.*.port", 1, 65535),
+		config.Range("redis.*.db", 0, 1<<16),
+	)
+	config.MarkSecret("redis.*.auth")
+	config.Defaults(map[string]interface{}{
+		"redis.*.port":     6379,
+		"redis.*.db":       0,
+		"redis.*.max_open": defaultPoolSize,
+		"redis.*.max_idle": defaultIdleSize,
+	})
+	// go-redis 内部日志输出到默认 logger
+	redis.SetLogger(logger.NewBridge("redis", zapcore.WarnLevel))
+}
+
 func InitRedis() {
 	redisConfigMap := config.GetStringMap("redis")
 
@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qkzsky/gutils/config"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	os.Clearenv()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	load := func(content string) (*config.Config, error) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c, err := config.Load(file)
+		if err != nil {
+			t.Fatalf("Load error: %v", err)
+		}
+		return c, c.Validate()
+	}
+
+	// 未配置 port 时使用 6379
+	c, err := load("redis:\n  default:\n    host: 127.0.0.1\n")
+	if err != nil {
+		t.Fatalf("Validate error: %v", err)
+	}
+	if c.GetInt("redis.default.port") != 6379 || c.Position("redis.default.port") != "default" {
+		t.Errorf("expected default port 6379, got %d from %s", c.GetInt("redis.default.port"), c.Position("redis.default.port"))
+	}
+
+	// host 必填，port 超出范围时报错
+	if _, err := load("redis:\n  default:\n    port: 70000\n"); err == nil ||
+		!strings.Contains(err.Error(), "redis.default.host") || !strings.Contains(err.Error(), "redis.default.port") {
+		t.Errorf("expected host and port errors, got %v", err)
+	}
+}
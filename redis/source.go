@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ConfigSource 基于 Redis hash 的远程配置来源，实现 config.Source
+// hash 的 field 为配置路径（如 log.level），value 为配置值；
+// 修改 hash 后向 channel 发布任意消息即可推送到各实例
+//
+//	HSET app:config log.level debug
+//	PUBLISH app:config:changed 1
+type ConfigSource struct {
+	client  redis.UniversalClient
+	key     string
+	channel string
+}
+
+// NewConfigSource 创建 Redis 配置来源，channel 为空时不订阅推送
+// client 需要独立于配置创建，如 redis.NewClient(&redis.Options{Addr: os.Getenv("CONFIG_REDIS")})
+func NewConfigSource(client redis.UniversalClient, key, channel string) *ConfigSource {
+	return &ConfigSource{client: client, key: key, channel: channel}
+}
+
+func (s *ConfigSource) Name() string {
+	return "redis:" + s.key
+}
+
+func (s *ConfigSource) Load(ctx context.Context) (map[string]string, error) {
+	return s.client.HGetAll(ctx, s.key).Result()
+}
+
+func (s *ConfigSource) Watch(ctx context.Context, notify func()) error {
+	if s.channel == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	sub := s.client.Subscribe(ctx, s.channel)
+	defer sub.Close()
+	// 确认订阅成功，连接失败时返回错误由调用方重试
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			notify()
+		}
+	}
+}
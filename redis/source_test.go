@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/qkzsky/gutils/config"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestConfigSource(t *testing.T) {
+	os.Clearenv()
+	mr := miniredis.RunT(t)
+	mr.HSet("app:config", "log.level", "debug", "app.workers", "8")
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("app:\n  workers: 4\nlog:\n  level: info\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache := filepath.Join(dir, "remote.json")
+
+	src := NewConfigSource(client, "app:config", "app:config:changed")
+	c, err := config.Load(file, config.WithSource(src, cache))
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if c.GetString("log.level") != "debug" || c.GetInt("app.workers") != 8 {
+		t.Fatalf("remote values not applied: %v %v", c.GetString("log.level"), c.GetInt("app.workers"))
+	}
+
+	changed := make(chan interface{}, 1)
+	c.OnChange("log.level", func(_, v interface{}) {
+		select {
+		case changed <- v:
+		default:
+		}
+	})
+	stop := c.Watch(time.Hour)
+	defer stop()
+
+	// 等待订阅建立后推送
+	deadline := time.Now().Add(2 * time.Second)
+	for len(mr.PubSubChannels("")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	mr.HSet("app:config", "log.level", "warn")
+	mr.Publish("app:config:changed", "1")
+
+	select {
+	case v := <-changed:
+		if v != "warn" {
+			t.Errorf("log.level expected warn, got %v", v)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("OnChange not called after publish")
+	}
+
+	// Redis 不可用时使用缓存中最近一次的配置
+	mr.Close()
+	c2, err := config.Load(file, config.WithSource(src, cache))
+	if err != nil {
+		t.Fatalf("Load with redis down error: %v", err)
+	}
+	if c2.GetString("log.level") != "warn" {
+		t.Errorf("log.level expected cached warn, got %s", c2.GetString("log.level"))
+	}
+}